@@ -0,0 +1,208 @@
+package pazuzu
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Directory of the bats distribution within the test archive
+const batsDir = "bats/"
+
+// batsCheck succeeds if bats is installed in the test container.
+const batsCheck = "command -v bats"
+
+type archiveEntry struct {
+	header   *tar.Header
+	contents []byte
+}
+
+// testArchive creates the archive copied to the test container. It contains the test files and,
+// if given, the bats distribution, both in mountPoint.
+// bats:	directory or .tar/.tar.gz archive of a bats distribution, may be empty
+func testArchive(testFiles []TestFile, bats string) ([]byte, error) {
+	root := strings.TrimPrefix(mountPoint, "/")
+	entries := []archiveEntry{
+		{&tar.Header{Name: root, Mode: 0755, Typeflag: tar.TypeDir}, nil},
+	}
+	directories := map[string]bool{}
+	for _, file := range testFiles {
+		// test files of features are kept in sub directories
+		for _, directory := range parentDirectories(file.Filename) {
+			if !directories[directory] {
+				directories[directory] = true
+				header := &tar.Header{Name: root + directory, Mode: 0755, Typeflag: tar.TypeDir}
+				entries = append(entries, archiveEntry{header, nil})
+			}
+		}
+		header := &tar.Header{Name: root + file.Filename, Mode: 0644, Size: int64(len(file.Contents))}
+		entries = append(entries, archiveEntry{header, file.Contents})
+	}
+
+	if bats != "" {
+		batsEntries, err := readBats(bats)
+		if err != nil {
+			return nil, fmt.Errorf("Can not read bats distribution %s: %s", bats, err)
+		}
+		for _, entry := range batsEntries {
+			entry.header.Name = root + batsDir + entry.header.Name
+			entries = append(entries, entry)
+		}
+	}
+
+	var buffer bytes.Buffer
+	archive := tar.NewWriter(&buffer)
+	for _, entry := range entries {
+		if err := archive.WriteHeader(entry.header); err != nil {
+			return nil, err
+		}
+		if _, err := archive.Write(entry.contents); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// parentDirectories returns the parent directories of a relative slash separated path,
+// outermost first, each with a trailing slash.
+func parentDirectories(name string) []string {
+	var directories []string
+	for i, c := range name {
+		if c == '/' {
+			directories = append(directories, name[:i+1])
+		}
+	}
+	return directories
+}
+
+// batsCommand returns the command running the test spec at the given path in the test container.
+// Results are reported in TAP format.
+func batsCommand(bats string, path string) string {
+	command := "bats"
+	if bats != "" {
+		command = mountPoint + batsDir + "bin/bats"
+	}
+	return fmt.Sprintf("%s -t %s", command, path)
+}
+
+// readBats reads the bats distribution with entry names relative to its root.
+func readBats(path string) ([]archiveEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return readDirectory(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := io.Reader(file)
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	return readArchive(reader)
+}
+
+func readDirectory(root string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		var contents []byte
+		if info.Mode().IsRegular() {
+			if contents, err = ioutil.ReadFile(path); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, archiveEntry{header, contents})
+		return nil
+	})
+	return entries, err
+}
+
+// readArchive reads a tar archive. Release archives contain a single top-level directory
+// (e.g. bats-0.4.0/), which is stripped.
+func readArchive(reader io.Reader) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		contents, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		header.Name = strings.TrimPrefix(header.Name, "./")
+		entries = append(entries, archiveEntry{header, contents})
+	}
+
+	prefix := commonTopDirectory(entries)
+	var result []archiveEntry
+	for _, entry := range entries {
+		entry.header.Name = strings.TrimPrefix(entry.header.Name, prefix)
+		if entry.header.Name != "" {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func commonTopDirectory(entries []archiveEntry) string {
+	prefix := ""
+	for _, entry := range entries {
+		parts := strings.SplitN(entry.header.Name, "/", 2)
+		if len(parts) < 2 {
+			return ""
+		}
+		if prefix != "" && prefix != parts[0]+"/" {
+			return ""
+		}
+		prefix = parts[0] + "/"
+	}
+	return prefix
+}
@@ -0,0 +1,104 @@
+package pazuzu
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func archiveNames(t *testing.T, archive []byte) map[string]string {
+	names := map[string]string{}
+	reader := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, _ := ioutil.ReadAll(reader)
+		names[header.Name] = string(contents) + header.Linkname
+	}
+}
+
+func TestTestArchiveFromDirectory(t *testing.T) {
+	directory, err := ioutil.TempDir("", "bats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	os.MkdirAll(filepath.Join(directory, "libexec"), 0755)
+	os.MkdirAll(filepath.Join(directory, "bin"), 0755)
+	ioutil.WriteFile(filepath.Join(directory, "libexec", "bats"), []byte("#!/bin/bash"), 0755)
+	os.Symlink("../libexec/bats", filepath.Join(directory, "bin", "bats"))
+
+	archive, err := testArchive([]TestFile{{Filename: TestSpecFilename, Contents: []byte("@test")}}, directory)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := map[string]string{
+		"pazuzu/":                  "",
+		"pazuzu/test.bats":         "@test",
+		"pazuzu/bats/bin/":         "",
+		"pazuzu/bats/bin/bats":     "../libexec/bats",
+		"pazuzu/bats/libexec/":     "",
+		"pazuzu/bats/libexec/bats": "#!/bin/bash",
+	}
+	if names := archiveNames(t, archive); !reflect.DeepEqual(names, want) {
+		t.Errorf("testArchive() = %v, want %v", names, want)
+	}
+}
+
+func TestTestArchiveFromTarball(t *testing.T) {
+	var tarball bytes.Buffer
+	gzipWriter := gzip.NewWriter(&tarball)
+	writer := tar.NewWriter(gzipWriter)
+	for _, name := range []string{"bats-0.4.0/", "bats-0.4.0/libexec/", "bats-0.4.0/libexec/bats"} {
+		writer.WriteHeader(&tar.Header{Name: name, Mode: 0755})
+	}
+	writer.Close()
+	gzipWriter.Close()
+
+	file, err := ioutil.TempFile("", "bats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.Write(tarball.Bytes())
+	file.Close()
+	path := file.Name() + ".tar.gz"
+	os.Rename(file.Name(), path)
+	defer os.Remove(path)
+
+	archive, err := testArchive([]TestFile{{Filename: TestSpecFilename}}, path)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := map[string]string{
+		"pazuzu/":                  "",
+		"pazuzu/test.bats":         "",
+		"pazuzu/bats/libexec/":     "",
+		"pazuzu/bats/libexec/bats": "",
+	}
+	if names := archiveNames(t, archive); !reflect.DeepEqual(names, want) {
+		t.Errorf("testArchive() = %v, want %v", names, want)
+	}
+}
+
+func TestBatsCommand(t *testing.T) {
+	if command := batsCommand("", "/pazuzu/test.bats"); command != "bats -t /pazuzu/test.bats" {
+		t.Errorf("bats from the image should be used: %s", command)
+	}
+	if command := batsCommand("/opt/bats", "/pazuzu/test.bats"); command != "/pazuzu/bats/bin/bats -t /pazuzu/test.bats" {
+		t.Errorf("uploaded bats should be used: %s", command)
+	}
+}
@@ -14,30 +14,57 @@ func ConfigShow(c *cli.Context) error {
 	if c.NArg() != 0 {
 		return errors.New("Wrong number of arguments")
 	}
-	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintf(writer, "Key\tValue\n")
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+	settings := []Setting{}
 	cfgMirror := config.GetConfigMirror()
 	for _, k := range cfgMirror.GetKeys() {
 		repr, errRepr := cfgMirror.GetRepr(k)
 		if errRepr == nil {
-			fmt.Fprintf(writer, "%s\t%s\n", k, repr)
+			settings = append(settings, Setting{Key: k, Value: repr})
 		}
 	}
-	writer.Flush()
-	return nil
+	return writeSettings(output, settings)
+}
+
+// writeSettings prints a list of settings in the requested output format.
+func writeSettings(output string, settings []Setting) error {
+	if output != OutputTable {
+		return writeStructured(os.Stdout, output, settings)
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(writer, "Key\tValue\n")
+	for _, setting := range settings {
+		fmt.Fprintf(writer, "%s\t%s\n", setting.Key, setting.Value)
+	}
+	return writer.Flush()
 }
 
 func ConfigGet(c *cli.Context) error {
 	if c.NArg() != 1 {
 		return errors.New("Wrong number of arguments")
 	}
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
 	key := c.Args().First()
 	cfgMirror := config.GetConfigMirror()
 	repr, err := cfgMirror.GetRepr(key)
 	if err != nil {
 		return pazuzu.ErrNotFound
 	}
-	fmt.Printf("%s => %s\n", key, repr)
+	return writeSetting(output, Setting{Key: key, Value: repr})
+}
+
+// writeSetting prints a single setting in the requested output format.
+func writeSetting(output string, setting Setting) error {
+	if output != OutputTable {
+		return writeStructured(os.Stdout, output, setting)
+	}
+	fmt.Printf("%s => %s\n", setting.Key, setting.Value)
 	return nil
 }
 
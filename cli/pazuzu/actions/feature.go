@@ -0,0 +1,292 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/cli/pazuzu/utils"
+	"github.com/zalando-incubator/pazuzu/config"
+	"github.com/zalando-incubator/pazuzu/shared"
+	storage "github.com/zalando-incubator/pazuzu/storageconnector"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	TreeOutputTree = "tree"
+	TreeOutputDot  = "dot"
+)
+
+// FeatureTree shows the resolved dependency tree of the project or of the given features.
+func FeatureTree(c *cli.Context) error {
+	output := c.String("format")
+	if output == "" {
+		output = TreeOutputTree
+	}
+	if output != TreeOutputTree && output != TreeOutputDot {
+		return fmt.Errorf("Unknown tree format: %s", output)
+	}
+
+	names := getFeaturesList(strings.Join(c.Args(), ","))
+	if len(names) == 0 {
+		destination := c.String("directory")
+		err := utils.CheckDestination(destination)
+		if err != nil {
+			return err
+		}
+		pazuzufilePath := utils.GetAbsoluteFilePath(destination, pazuzu.PazuzufileName)
+		pazuzuFile, success := utils.ReadPazuzuFile(pazuzufilePath)
+		if !success {
+			return fmt.Errorf("Can not read configuration: %s", pazuzufilePath)
+		}
+		names = pazuzuFile.Features
+	}
+	if len(names) == 0 {
+		return errors.New("ERROR: no features to show")
+	}
+
+	storageReader, err := config.GetStorageReader(*config.GetConfig())
+	if err != nil {
+		return fmt.Errorf("Error during storage setup:%s", err)
+	}
+
+	_, features, err := storageReader.Resolve(names...)
+	if err != nil {
+		return err
+	}
+
+	var roots []string
+	for _, name := range names {
+		if root, ok := shared.LookupDependency(features, name); ok {
+			roots = append(roots, root)
+		}
+	}
+
+	why := c.String("why")
+	if why == "" {
+		if output == TreeOutputDot {
+			return writeDependencyGraph(os.Stdout, dependencyEdges(roots, features))
+		}
+		return writeDependencyTree(os.Stdout, roots, features)
+	}
+
+	target, ok := shared.LookupDependency(features, why)
+	if !ok {
+		return fmt.Errorf("Feature %s is not part of the resolved features", why)
+	}
+	paths := shared.DependencyPaths(roots, features, target)
+	if output == TreeOutputDot {
+		return writeDependencyGraph(os.Stdout, pathEdges(paths))
+	}
+	for _, path := range paths {
+		fmt.Println(strings.Join(path, " -> "))
+	}
+	return nil
+}
+
+// writeDependencyTree writes the dependency tree of the given resolved features.
+// Features which were already expanded are marked with (*) and not expanded again.
+func writeDependencyTree(writer io.Writer, roots []string, features map[string]shared.Feature) error {
+	expanded := map[string]bool{}
+
+	var write func(name string, prefix string, childPrefix string) error
+	write = func(name string, prefix string, childPrefix string) error {
+		dependencies := shared.ResolvedDependencies(features, name)
+		if expanded[name] && len(dependencies) > 0 {
+			_, err := fmt.Fprintf(writer, "%s%s (*)\n", prefix, name)
+			return err
+		}
+		expanded[name] = true
+
+		if _, err := fmt.Fprintf(writer, "%s%s\n", prefix, name); err != nil {
+			return err
+		}
+		for i, dependency := range dependencies {
+			branch, indent := "├── ", "│   "
+			if i == len(dependencies)-1 {
+				branch, indent = "└── ", "    "
+			}
+			if err := write(dependency, childPrefix+branch, childPrefix+indent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := write(root, "", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type dependencyEdge struct {
+	From string
+	To   string
+}
+
+func dependencyEdges(roots []string, features map[string]shared.Feature) []dependencyEdge {
+	var edges []dependencyEdge
+	visited := map[string]bool{}
+
+	var walk func(name string)
+	walk = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dependency := range shared.ResolvedDependencies(features, name) {
+			edges = append(edges, dependencyEdge{name, dependency})
+			walk(dependency)
+		}
+	}
+
+	for _, root := range roots {
+		edges = append(edges, dependencyEdge{From: root})
+		walk(root)
+	}
+	return edges
+}
+
+func pathEdges(paths [][]string) []dependencyEdge {
+	var edges []dependencyEdge
+	seen := map[dependencyEdge]bool{}
+
+	for _, path := range paths {
+		candidates := []dependencyEdge{{From: path[0]}}
+		for i := 1; i < len(path); i++ {
+			candidates = append(candidates, dependencyEdge{path[i-1], path[i]})
+		}
+		for _, edge := range candidates {
+			if !seen[edge] {
+				seen[edge] = true
+				edges = append(edges, edge)
+			}
+		}
+	}
+	return edges
+}
+
+// writeDependencyGraph writes dependency edges as a Graphviz graph. Edges without a target
+// mark the requested features, which are drawn bold.
+func writeDependencyGraph(writer io.Writer, edges []dependencyEdge) error {
+	lines := []string{"digraph pazuzu {"}
+	for _, edge := range edges {
+		if edge.To == "" {
+			lines = append(lines, fmt.Sprintf("\t%q [style=bold];", edge.From))
+		} else {
+			lines = append(lines, fmt.Sprintf("\t%q -> %q;", edge.From, edge.To))
+		}
+	}
+	lines = append(lines, "}")
+
+	_, err := fmt.Fprintln(writer, strings.Join(lines, "\n"))
+	return err
+}
+
+// FeatureDetails is a full description of a feature, as shown by `feature info`.
+type FeatureDetails struct {
+	Name                 string   `json:"name" yaml:"name"`
+	Description          string   `json:"description" yaml:"description"`
+	Author               string   `json:"author" yaml:"author"`
+	UpdatedAt            string   `json:"updated_at" yaml:"updated_at"`
+	Dependencies         []string `json:"dependencies" yaml:"dependencies"`
+	OptionalDependencies []string `json:"optional_dependencies" yaml:"optional_dependencies"`
+	TestDependencies     []string `json:"test_dependencies" yaml:"test_dependencies"`
+	TestRunner           string   `json:"test_runner" yaml:"test_runner"`
+	Conflicts            []string `json:"conflicts" yaml:"conflicts"`
+	Provides             []string `json:"provides" yaml:"provides"`
+	RequiredBy           []string `json:"required_by,omitempty" yaml:"required_by,omitempty"`
+	Snippet              string   `json:"snippet" yaml:"snippet"`
+	TestSnippet          string   `json:"test_snippet" yaml:"test_snippet"`
+}
+
+func NewFeatureDetails(feature shared.Feature, reverseDependencies []shared.FeatureMeta) FeatureDetails {
+	info := FeatureDetails{
+		Name:                 feature.Meta.Name,
+		Description:          feature.Meta.Description,
+		Author:               feature.Meta.Author,
+		UpdatedAt:            formatUpdatedAt(feature.Meta.UpdatedAt),
+		Dependencies:         nonNil(feature.Meta.Dependencies),
+		OptionalDependencies: nonNil(feature.Meta.OptionalDependencies),
+		TestDependencies:     nonNil(feature.Meta.TestDependencies),
+		TestRunner:           pazuzu.TestRunnerName(feature),
+		Conflicts:            nonNil(feature.Meta.Conflicts),
+		Provides:             nonNil(feature.Meta.Provides),
+		Snippet:              feature.Snippet,
+		TestSnippet:          feature.TestSnippet,
+	}
+	for _, meta := range reverseDependencies {
+		info.RequiredBy = append(info.RequiredBy, meta.Name)
+	}
+	return info
+}
+
+// FeatureInfo shows all details of a feature.
+func FeatureInfo(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("Wrong number of arguments")
+	}
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+
+	storageReader, err := config.GetStorageReader(*config.GetConfig())
+	if err != nil {
+		return fmt.Errorf("Error during storage setup:%s", err)
+	}
+
+	feature, err := storageReader.GetFeature(c.Args().First())
+	if err != nil {
+		return featureLookupError(storageReader, c.Args().First(), err)
+	}
+
+	// reverse dependencies are optional, not every storage is able to list them
+	reverseDependencies, err := storage.ReverseDependencies(storageReader, feature.Meta)
+	if err != nil {
+		log.Printf("Can not look up features depending on %s: %s\n", feature.Meta.Name, err)
+	}
+
+	info := NewFeatureDetails(feature, reverseDependencies)
+	if output != OutputTable {
+		return writeStructured(os.Stdout, output, info)
+	}
+	return writeFeatureDetails(os.Stdout, info)
+}
+
+// featureLookupError explains why the feature could not be fetched: unknown features are
+// reported with suggestions, other errors of the storage are returned as they are.
+func featureLookupError(storageReader storage.StorageReader, name string, err error) error {
+	if errCheck := pazuzu.CheckFeatures(storageReader, []string{name}); errCheck != nil {
+		return errCheck
+	}
+	return fmt.Errorf("Can not get feature %s: %s", name, err)
+}
+
+func writeFeatureDetails(writer io.Writer, info FeatureDetails) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(tw, "Name\t%s\n", info.Name)
+	fmt.Fprintf(tw, "Description\t%s\n", info.Description)
+	fmt.Fprintf(tw, "Author\t%s\n", info.Author)
+	fmt.Fprintf(tw, "Updated at\t%s\n", info.UpdatedAt)
+	fmt.Fprintf(tw, "Dependencies\t%s\n", strings.Join(info.Dependencies, ", "))
+	fmt.Fprintf(tw, "Optional dependencies\t%s\n", strings.Join(info.OptionalDependencies, ", "))
+	fmt.Fprintf(tw, "Test dependencies\t%s\n", strings.Join(info.TestDependencies, ", "))
+	fmt.Fprintf(tw, "Test runner\t%s\n", info.TestRunner)
+	fmt.Fprintf(tw, "Conflicts\t%s\n", strings.Join(info.Conflicts, ", "))
+	fmt.Fprintf(tw, "Provides\t%s\n", strings.Join(info.Provides, ", "))
+	fmt.Fprintf(tw, "Required by\t%s\n", strings.Join(info.RequiredBy, ", "))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(writer, "\nSnippet:\n%s\n\nTest snippet:\n%s\n",
+		strings.TrimRight(info.Snippet, "\n"), strings.TrimRight(info.TestSnippet, "\n"))
+	return err
+}
@@ -0,0 +1,128 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando-incubator/pazuzu/mock"
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+func testResolvedFeatures() map[string]shared.Feature {
+	features := map[string]shared.Feature{}
+	for _, meta := range []shared.FeatureMeta{
+		{Name: "app", Dependencies: []string{"python2", "curl"}},
+		{Name: "python2", Dependencies: []string{"curl"}},
+		{Name: "curl", Dependencies: []string{"libc"}},
+		{Name: "libc"},
+	} {
+		features[meta.Name] = shared.Feature{Meta: meta}
+	}
+	return features
+}
+
+func TestWriteDependencyTree(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeDependencyTree(&buf, []string{"app"}, testResolvedFeatures())
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := `app
+├── python2
+│   └── curl
+│       └── libc
+└── curl (*)
+`
+	if buf.String() != want {
+		t.Errorf("writeDependencyTree() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteDependencyGraph(t *testing.T) {
+	features := testResolvedFeatures()
+	paths := shared.DependencyPaths([]string{"app"}, features, "curl")
+
+	var buf bytes.Buffer
+	err := writeDependencyGraph(&buf, pathEdges(paths))
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := `digraph pazuzu {
+	"app" [style=bold];
+	"app" -> "python2";
+	"python2" -> "curl";
+	"app" -> "curl";
+}
+`
+	if buf.String() != want {
+		t.Errorf("writeDependencyGraph() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFeatureDetails(t *testing.T) {
+	feature := shared.Feature{
+		Meta: shared.FeatureMeta{
+			Name:         "maven",
+			Author:       "Pazuzu",
+			UpdatedAt:    time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC),
+			Dependencies: []string{"jdk"},
+		},
+		Snippet:     "RUN apt-get install maven --yes",
+		TestSnippet: "@test \"maven\" {\n  mvn -v\n}",
+	}
+	details := NewFeatureDetails(feature, []shared.FeatureMeta{{Name: "app"}})
+
+	var buf bytes.Buffer
+	if err := writeStructured(&buf, OutputJSON, details); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	var decoded FeatureDetails
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("should be valid JSON: %s", err)
+	}
+	if !reflect.DeepEqual(decoded, details) {
+		t.Errorf("decoded = %v, want %v", decoded, details)
+	}
+	if details.UpdatedAt != "2017-03-01T10:00:00Z" || details.RequiredBy[0] != "app" {
+		t.Errorf("Unexpected details: %v", details)
+	}
+
+	buf.Reset()
+	if err := writeFeatureDetails(&buf, details); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	for _, part := range []string{"Dependencies            jdk", "Required by             app", "mvn -v"} {
+		if !strings.Contains(buf.String(), part) {
+			t.Errorf("Output should contain %q:\n%s", part, buf.String())
+		}
+	}
+}
+
+func TestFeatureLookupError(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "python"}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node"}},
+	)
+	failure := errors.New("503 Service Unavailable")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"pyton", "Feature pyton not found, did you mean: python?"},
+		{"python", "Can not get feature python: 503 Service Unavailable"},
+	}
+	for _, tt := range tests {
+		if err := featureLookupError(storage, tt.name, failure); err == nil || err.Error() != tt.want {
+			t.Errorf("featureLookupError(%s) = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/urfave/cli"
+	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/shared"
+	"gopkg.in/yaml.v2"
+	"io"
+	"time"
+)
+
+// Output formats supported by the commands.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// FeatureSummary is a short description of a feature, as listed by `search`.
+type FeatureSummary struct {
+	Name         string   `json:"name" yaml:"name"`
+	Author       string   `json:"author" yaml:"author"`
+	Description  string   `json:"description" yaml:"description"`
+	UpdatedAt    string   `json:"updated_at" yaml:"updated_at"`
+	Dependencies []string `json:"dependencies" yaml:"dependencies"`
+}
+
+func NewFeatureSummary(meta shared.FeatureMeta) FeatureSummary {
+	return FeatureSummary{
+		Name:         meta.Name,
+		Author:       meta.Author,
+		Description:  meta.Description,
+		UpdatedAt:    formatUpdatedAt(meta.UpdatedAt),
+		Dependencies: nonNil(meta.Dependencies),
+	}
+}
+
+// Setting is a single configuration key and its value, as shown by `config show/get`
+// and `project show`.
+type Setting struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// BuildResult is the outcome of `project build`.
+type BuildResult struct {
+	Image      string   `json:"image" yaml:"image"`
+	Dockerfile string   `json:"dockerfile" yaml:"dockerfile"`
+	TestSpec   string   `json:"test_spec" yaml:"test_spec"`
+	Features   []string `json:"features" yaml:"features"`
+	Success    bool     `json:"success" yaml:"success"`
+	Error      string   `json:"error,omitempty" yaml:"error,omitempty"`
+
+	Tests []pazuzu.FeatureTestResults `json:"tests" yaml:"tests"`
+}
+
+// TestRunResult is the outcome of `project test`.
+type TestRunResult struct {
+	Image   string                      `json:"image" yaml:"image"`
+	Success bool                        `json:"success" yaml:"success"`
+	Error   string                      `json:"error,omitempty" yaml:"error,omitempty"`
+	Tests   []pazuzu.FeatureTestResults `json:"tests" yaml:"tests"`
+}
+
+// ProjectDiffResult is the outcome of `project diff`.
+type ProjectDiffResult struct {
+	Files    []FileDiff             `json:"files" yaml:"files"`
+	Features []pazuzu.FeatureChange `json:"features" yaml:"features"`
+}
+
+// FileDiff is the unified diff of a generated file, empty if it is up to date.
+type FileDiff struct {
+	Path string `json:"path" yaml:"path"`
+	Diff string `json:"diff" yaml:"diff"`
+}
+
+// outputFormat returns the output format requested for the command. The command's own
+// `--output` flag takes precedence over the global one.
+func outputFormat(c *cli.Context) (string, error) {
+	format := c.String("output")
+	if format == "" {
+		format = c.GlobalString("output")
+	}
+	return checkOutputFormat(format)
+}
+
+func checkOutputFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return OutputTable, nil
+	case OutputTable, OutputJSON, OutputYAML:
+		return format, nil
+	}
+	return "", fmt.Errorf("Unknown output format: %s", format)
+}
+
+// writeStructured writes the value as JSON or YAML document.
+func writeStructured(writer io.Writer, format string, value interface{}) error {
+	var data []byte
+	var err error
+
+	switch format {
+	case OutputJSON:
+		data, err = json.MarshalIndent(value, "", "  ")
+		data = append(data, '\n')
+	case OutputYAML:
+		data, err = yaml.Marshal(value)
+	default:
+		return fmt.Errorf("Unknown output format: %s", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+	return err
+}
+
+func formatUpdatedAt(updatedAt time.Time) string {
+	if updatedAt.IsZero() {
+		return ""
+	}
+	return updatedAt.Format(time.RFC3339)
+}
+
+func nonNil(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
+
+func nonNilResults(results []pazuzu.FeatureTestResults) []pazuzu.FeatureTestResults {
+	if results == nil {
+		return []pazuzu.FeatureTestResults{}
+	}
+	return results
+}
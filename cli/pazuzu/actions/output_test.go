@@ -0,0 +1,63 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+func TestCheckOutputFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"", OutputTable, false},
+		{"table", OutputTable, false},
+		{"json", OutputJSON, false},
+		{"yaml", OutputYAML, false},
+		{"xml", "", true},
+	}
+	for _, tt := range tests {
+		got, err := checkOutputFormat(tt.format)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("checkOutputFormat(%q) = %q, %v, want %q", tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestWriteStructured(t *testing.T) {
+	summaries := []FeatureSummary{NewFeatureSummary(shared.FeatureMeta{Name: "python", Author: "Pazuzu"})}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{OutputJSON, `[
+  {
+    "name": "python",
+    "author": "Pazuzu",
+    "description": "",
+    "updated_at": "",
+    "dependencies": []
+  }
+]
+`},
+		{OutputYAML, `- name: python
+  author: Pazuzu
+  description: ""
+  updated_at: ""
+  dependencies: []
+`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeStructured(&buf, tt.format, summaries); err != nil {
+			t.Fatalf("should not fail: %s", err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("writeStructured(%s) =\n%s\nwant\n%s", tt.format, buf.String(), tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package actions
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/satori/go.uuid"
@@ -8,67 +9,97 @@ import (
 	"github.com/zalando-incubator/pazuzu"
 	"github.com/zalando-incubator/pazuzu/cli/pazuzu/utils"
 	"github.com/zalando-incubator/pazuzu/config"
+	"github.com/zalando-incubator/pazuzu/shared"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 )
 
+// ProjectClean removes the files generated by pazuzu. Files which were edited manually
+// since and Pazuzufile itself are only removed with --force.
 func ProjectClean(c *cli.Context) error {
-	err := os.Remove(pazuzu.PazuzufileName)
+	directory := c.String("directory")
+	err := utils.CheckDestination(directory)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
-	err = os.Remove(pazuzu.DockerfileName)
-	if err != nil {
-		fmt.Println(err)
+	force := c.Bool("force")
+
+	for _, name := range append(append([]string{pazuzu.DockerfileName}, pazuzu.TestFilenames()...), pazuzu.LockfileName) {
+		path := utils.GetAbsoluteFilePath(directory, name)
+		contents, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if generated, modified := pazuzu.CheckGenerated(contents); !force && (!generated || modified) {
+			fmt.Printf("Keeping %s, it was not generated by pazuzu or was edited since\n", path)
+			continue
+		}
+		err = os.Remove(path)
+		if err != nil {
+			fmt.Println(err)
+		}
 	}
-	err = os.Remove(pazuzu.TestSpecFilename)
-	if err != nil {
+
+	pazuzufilePath := utils.GetAbsoluteFilePath(directory, pazuzu.PazuzufileName)
+	if !force {
+		fmt.Printf("Keeping %s, use --force to remove it\n", pazuzufilePath)
+		return nil
+	}
+	err = os.Remove(pazuzufilePath)
+	if err != nil && !os.IsNotExist(err) {
 		fmt.Println(err)
 	}
 	return nil
 }
 
 func ProjectBuild(c *cli.Context) error {
-	directory := c.String("directory")
-	err := utils.CheckDestination(directory)
+	output, err := outputFormat(c)
 	if err != nil {
-		return fmt.Errorf("Error to access directory:%s\n%s", directory, err)
+		return err
 	}
-
-	pazuzufilePath := utils.GetAbsoluteFilePath(directory, pazuzu.PazuzufileName)
-	dockerfilePath := utils.GetAbsoluteFilePath(directory, pazuzu.DockerfileName)
-	testSpecPath := utils.GetAbsoluteFilePath(directory, pazuzu.TestSpecFilename)
-	pazuzuFile, success := utils.ReadPazuzuFile(pazuzufilePath)
-	if !success {
-		return fmt.Errorf("Can not read configuration: %s\n", pazuzufilePath)
+	// progress is kept out of the way of machine-readable results
+	progress := io.Writer(os.Stdout)
+	if output != OutputTable {
+		progress = os.Stderr
 	}
 
-	storageReader, err := config.GetStorageReader(*config.GetConfig())
+	directory := c.String("directory")
+	err = utils.CheckDestination(directory)
 	if err != nil {
-		return fmt.Errorf("Error during storage setup:%s", err)
+		return fmt.Errorf("Error to access directory:%s\n%s", directory, err)
 	}
 
-	p := pazuzu.Pazuzu{StorageReader: storageReader}
-	p.Generate(pazuzuFile.Base, pazuzuFile.Features)
-	fmt.Printf("Generating %s...\n", dockerfilePath)
-	err = utils.WriteFile(dockerfilePath, p.Dockerfile)
+	p, pazuzuFile, files, err := generateProjectFiles(c, directory, progress)
 	if err != nil {
-		return fmt.Errorf("Can not write Dockerfile: %s\n%s", dockerfilePath, err)
+		return err
 	}
-	fmt.Printf("Generating %s...\n", testSpecPath)
-	err = utils.WriteFile(testSpecPath, p.TestSpec)
+	err = writeProjectFiles(directory, p, files, c.Bool("force"), progress)
 	if err != nil {
-		return fmt.Errorf("Can not write TestSpec: %s\n%s", testSpecPath, err)
+		return err
 	}
+	dockerfilePath := utils.GetAbsoluteFilePath(directory, pazuzu.DockerfileName)
+	testSpecPath := utils.GetAbsoluteFilePath(directory, pazuzu.TestSpecFilename)
 
 	dat, err := ioutil.ReadFile(dockerfilePath)
 	if err != nil {
 		return fmt.Errorf("Error during attempt to read docker file:%s", err)
 	}
 
-	p.DockerEndpoint = pazuzu.DefaultDockerEndpoint
+	p.Docker = config.GetConfig().DockerOptions()
 	p.Dockerfile = dat
+	err = configureTests(c, p)
+	if err != nil {
+		return err
+	}
 
 	name := ""
 	if c.String("name") != "" {
@@ -76,26 +107,185 @@ func ProjectBuild(c *cli.Context) error {
 	} else {
 		name = strings.Replace(uuid.NewV1().String(), "-", "", -1)
 	}
+	result := BuildResult{
+		Image:      name,
+		Dockerfile: dockerfilePath,
+		TestSpec:   testSpecPath,
+		Features:   nonNil(pazuzuFile.Features),
+	}
 	err2 := p.DockerBuild(name)
+	result.Success = err2 == nil
+	if err2 != nil {
+		result.Error = err2.Error()
+	}
+	result.Tests = nonNilResults(p.TestResults)
+
+	err = writeTestReports(c.StringSlice("report"), p.TestResults, progress)
+	if err != nil {
+		return err
+	}
+
+	err = writeBuildResult(output, result)
+	if err != nil {
+		return err
+	}
 	if err2 != nil {
 		return fmt.Errorf("should not fail: %s", err2)
 	}
 	return nil
 }
 
+// ProjectTest runs the tests of the project features against an existing image, e.g. one
+// built by another pipeline. Features are resolved with the versions recorded in the lockfile.
+func ProjectTest(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("Wrong number of arguments, the image name or ID is required")
+	}
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+	progress := io.Writer(os.Stdout)
+	if output != OutputTable {
+		progress = os.Stderr
+	}
+
+	directory := c.String("directory")
+	err = utils.CheckDestination(directory)
+	if err != nil {
+		return fmt.Errorf("Error to access directory:%s\n%s", directory, err)
+	}
+
+	pinned, err := pinnedFeatures(directory)
+	if err != nil {
+		return err
+	}
+	p, _, err := generateProject(directory, c.Bool("with-test-dependencies"), pinned, progress)
+	if err != nil {
+		return err
+	}
+	p.Docker = config.GetConfig().DockerOptions()
+	p.SelectedTests = getFeaturesList(c.String("features"))
+	err = configureTests(c, p)
+	if err != nil {
+		return err
+	}
+
+	image := c.Args().First()
+	err2 := p.DockerTest(image)
+	result := TestRunResult{
+		Image:   image,
+		Success: err2 == nil,
+		Tests:   nonNilResults(p.TestResults),
+	}
+	if err2 != nil {
+		result.Error = err2.Error()
+	}
+
+	err = writeTestReports(c.StringSlice("report"), p.TestResults, progress)
+	if err != nil {
+		return err
+	}
+
+	if output != OutputTable {
+		err = writeStructured(os.Stdout, output, result)
+		if err != nil {
+			return err
+		}
+	}
+	return err2
+}
+
+// configureTests sets up how tests are run from the configuration and the command flags.
+func configureTests(c *cli.Context, p *pazuzu.Pazuzu) error {
+	p.Bats = config.GetConfig().Bats
+	p.KeepContainer = c.Bool("keep-container")
+
+	switch {
+	case c.Bool("quiet") && c.GlobalBool("verbose"):
+		return errors.New("Only one of --quiet and --verbose can be used")
+	case c.Bool("quiet"):
+		p.Verbosity = pazuzu.VerbosityQuiet
+	case c.GlobalBool("verbose"):
+		p.Verbosity = pazuzu.VerbosityVerbose
+	}
+
+	timeout := config.GetConfig().TestTimeout
+	if c.String("timeout") != "" {
+		timeout = c.String("timeout")
+	}
+	if timeout != "" {
+		duration, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("Invalid test timeout %s: %s", timeout, err)
+		}
+		p.TestTimeout = duration
+	}
+	return nil
+}
+
+// writeTestReports writes reports of the test results, also if tests failed.
+func writeTestReports(paths []string, results []pazuzu.FeatureTestResults, progress io.Writer) error {
+	for _, path := range paths {
+		if err := writeTestReport(path, results); err != nil {
+			return fmt.Errorf("Error writing test report %s: %s", path, err)
+		}
+		fmt.Fprintf(progress, "Test report written to %s\n", path)
+	}
+	return nil
+}
+
+// writeTestReport writes the test results to a JSON report if the path ends with .json,
+// JUnit XML otherwise.
+func writeTestReport(path string, results []pazuzu.FeatureTestResults) error {
+	var buffer bytes.Buffer
+	var err error
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = pazuzu.WriteJSONReport(&buffer, results)
+	} else {
+		err = pazuzu.WriteJUnitReport(&buffer, results)
+	}
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(path, buffer.Bytes())
+}
+
+func writeBuildResult(output string, result BuildResult) error {
+	if output != OutputTable {
+		return writeStructured(os.Stdout, output, result)
+	}
+	status := "success"
+	if !result.Success {
+		status = "failure"
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(writer, "Image\t%s\n", result.Image)
+	fmt.Fprintf(writer, "Result\t%s\n", status)
+	return writer.Flush()
+}
+
 func ProjectListFeatures(c *cli.Context) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
 	destination := c.String("directory")
-	err := utils.CheckDestination(destination)
+	err = utils.CheckDestination(destination)
 	if err != nil {
 		return err
 	}
 	pazuzufilePath := utils.GetAbsoluteFilePath(destination, pazuzu.PazuzufileName)
 	pazuzuFile, success := utils.ReadPazuzuFile(pazuzufilePath)
+	pazuzufileFeatures := []string{}
 	if success {
-		pazuzufileFeatures := pazuzuFile.Features
-		for _, feature := range pazuzufileFeatures {
-			fmt.Println(feature)
-		}
+		pazuzufileFeatures = nonNil(pazuzuFile.Features)
+	}
+	if output != OutputTable {
+		return writeStructured(os.Stdout, output, pazuzufileFeatures)
+	}
+	for _, feature := range pazuzufileFeatures {
+		fmt.Println(feature)
 	}
 	return nil
 }
@@ -119,7 +309,6 @@ func ProjectRemoveFeatures(c *cli.Context) error {
 	}
 
 	newFeatures := pazuzuFile.Features
-	baseImage := pazuzuFile.Base
 
 loop:
 	for i := 0; i < len(newFeatures); i++ {
@@ -133,7 +322,8 @@ loop:
 		}
 	}
 
-	err = generateFiles(destination, baseImage, newFeatures)
+	pazuzuFile.Features = newFeatures
+	err = generateFiles(destination, pazuzuFile)
 	if err != nil {
 		return err
 	}
@@ -155,19 +345,16 @@ func ProjectAddFeatures(c *cli.Context) error {
 
 	pazuzufilePath := utils.GetAbsoluteFilePath(destination, pazuzu.PazuzufileName)
 	pazuzuFile, success := utils.ReadPazuzuFile(pazuzufilePath)
-	baseImage := config.GetConfig().Base
-	var currentFeatures []string
-	if success {
-		baseImage = pazuzuFile.Base
-		currentFeatures = pazuzuFile.Features
+	if !success {
+		pazuzuFile = &pazuzu.PazuzuFile{Base: config.GetConfig().Base}
 	}
 	for _, f := range features {
-		if !isFeatureInList(currentFeatures, f) {
-			currentFeatures = append(currentFeatures, f)
+		if !isFeatureInList(pazuzuFile.Features, f) {
+			pazuzuFile.Features = append(pazuzuFile.Features, f)
 		}
 	}
 
-	err = generateFiles(destination, baseImage, currentFeatures)
+	err = generateFiles(destination, pazuzuFile)
 	if err != nil {
 		return err
 	}
@@ -176,13 +363,16 @@ func ProjectAddFeatures(c *cli.Context) error {
 }
 
 func ProjectShow(c *cli.Context) error {
-	if c.NArg() != 1 {
+	if c.NArg() > 1 {
 		return errors.New("Wrong number of arguments")
 	}
-	key := c.Args().First()
 	destination := c.String("directory")
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
 
-	err := utils.CheckDestination(destination)
+	err = utils.CheckDestination(destination)
 	if err != nil {
 		return err
 	}
@@ -192,10 +382,22 @@ func ProjectShow(c *cli.Context) error {
 	if !success {
 		return errors.New("Project doesn't have configuration yet")
 	}
-	if key == "base" {
-		fmt.Printf("%s => %s\n", key, pazuzuFile.Base)
+
+	if c.NArg() == 1 {
+		key := c.Args().First()
+		value, err := pazuzuFile.GetSetting(key)
+		if err != nil {
+			return err
+		}
+		return writeSetting(output, Setting{Key: key, Value: value})
 	}
-	return nil
+
+	settings := []Setting{}
+	for _, key := range pazuzu.SettingKeys() {
+		value, _ := pazuzuFile.GetSetting(key)
+		settings = append(settings, Setting{Key: key, Value: value})
+	}
+	return writeSettings(output, settings)
 }
 
 func ProjectSet(c *cli.Context) error {
@@ -211,35 +413,30 @@ func ProjectSet(c *cli.Context) error {
 		return err
 	}
 
-	var features []string
-	base := config.GetConfig().Base
-
 	pazuzufilePath := utils.GetAbsoluteFilePath(destination, pazuzu.PazuzufileName)
 	pazuzuFile, success := utils.ReadPazuzuFile(pazuzufilePath)
-
-	if success {
-		base = pazuzuFile.Base
-		features = pazuzuFile.Features
-	}
-
-	if key != "base" {
-		return errors.New("Key is not supported")
+	if !success {
+		pazuzuFile = &pazuzu.PazuzuFile{Base: config.GetConfig().Base}
 	}
 
-	if base == value {
-		// nothing changes so return
-		return nil
+	previous, err := pazuzuFile.GetSetting(key)
+	if err != nil {
+		return err
 	}
-
-	err = generateFiles(destination, base, features)
+	err = pazuzuFile.SetSetting(key, value)
 	if err != nil {
 		return err
 	}
+	if current, _ := pazuzuFile.GetSetting(key); success && current == previous {
+		// nothing changes so return
+		return nil
+	}
 
-	return nil
+	return generateFiles(destination, pazuzuFile)
 }
 
-func generateFiles(destination string, baseImage string, featureNames []string) error {
+// generateFiles checks the features of the project and writes its Pazuzufile.
+func generateFiles(destination string, pazuzuFile *pazuzu.PazuzuFile) error {
 	err := utils.CheckDestination(destination)
 	if err != nil {
 		return err
@@ -249,20 +446,25 @@ func generateFiles(destination string, baseImage string, featureNames []string)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Resolving the following features: %s\n", featureNames)
-	features, err := utils.CheckFeaturesInRepository(featureNames, storageReader)
+	fmt.Printf("Resolving the following features: %s\n", pazuzuFile.Features)
+	features, err := utils.CheckFeaturesInRepository(pazuzuFile.Features, storageReader)
 	if err != nil {
 		return err
 	}
-	if baseImage == "" {
-		baseImage = config.GetConfig().Base
+	_, resolved, err := storageReader.Resolve(features...)
+	if err != nil {
+		return err
+	}
+	err = shared.CheckConflicts(features, resolved)
+	if err != nil {
+		return err
+	}
+	pazuzuFile.Features = features
+	if pazuzuFile.Base == "" {
+		pazuzuFile.Base = config.GetConfig().Base
 	}
 
 	fmt.Printf("Generating %s...\n", pazuzufilePath)
-	pazuzuFile := &pazuzu.PazuzuFile{
-		Base:     baseImage,
-		Features: features,
-	}
 	err = utils.WritePazuzuFile(pazuzufilePath, pazuzuFile)
 	if err != nil {
 		return err
@@ -0,0 +1,110 @@
+package actions
+
+import (
+	"fmt"
+	"github.com/urfave/cli"
+	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/cli/pazuzu/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+)
+
+// ProjectDiff shows what regenerating the project would change: the unified diff of the
+// generated files and the features added, removed or updated since the last generation.
+func ProjectDiff(c *cli.Context) error {
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+
+	directory := c.String("directory")
+	err = utils.CheckDestination(directory)
+	if err != nil {
+		return fmt.Errorf("Error to access directory:%s\n%s", directory, err)
+	}
+
+	lockfile, err := utils.ReadLockfile(utils.GetAbsoluteFilePath(directory, pazuzu.LockfileName))
+	if err != nil {
+		return err
+	}
+	// the files are compared to what generate would write, so the locked versions are used,
+	// while the features are compared to the latest versions in the registry
+	p, _, err := generateProject(directory, c.Bool("with-test-dependencies"), lockfile.Pinned(), os.Stderr)
+	if err != nil {
+		return err
+	}
+	latest, _, err := generateProject(directory, c.Bool("with-test-dependencies"), nil, ioutil.Discard)
+	if err != nil {
+		return err
+	}
+
+	result, err := diffProject(directory, lockfile, p, latest)
+	if err != nil {
+		return err
+	}
+
+	if output != OutputTable {
+		return writeStructured(os.Stdout, output, result)
+	}
+	for _, file := range result.Files {
+		fmt.Print(file.Diff)
+	}
+	return writeFeatureChanges(os.Stdout, result.Features)
+}
+
+// diffProject compares the files on disk to the generated ones and the locked features to
+// the latest resolution.
+func diffProject(directory string, lockfile pazuzu.Lockfile, generated *pazuzu.Pazuzu, latest *pazuzu.Pazuzu) (ProjectDiffResult, error) {
+	result := ProjectDiffResult{Files: []FileDiff{}}
+	for _, file := range generatedFiles(directory, generated) {
+		current, err := ioutil.ReadFile(file.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return result, err
+		}
+		diff := utils.UnifiedDiff(file.Path, file.Path+" (generated)", current, file.Contents)
+		result.Files = append(result.Files, FileDiff{Path: file.Path, Diff: diff})
+	}
+
+	result.Features = pazuzu.DiffLockfiles(lockfile, latest.Lockfile)
+	return result, nil
+}
+
+// writeFeatureChanges lists the changed features, marked with +, - or ~ for added,
+// removed and updated ones, together with their old and new versions.
+func writeFeatureChanges(writer io.Writer, changes []pazuzu.FeatureChange) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(writer, "No feature changes")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tFEATURE\tOLD\tNEW")
+	for _, change := range changes {
+		mark := "~"
+		switch change.Change {
+		case pazuzu.FeatureAdded:
+			mark = "+"
+		case pazuzu.FeatureRemoved:
+			mark = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", mark, change.Name, lockedVersion(change.Old), lockedVersion(change.New))
+	}
+	return tw.Flush()
+}
+
+// lockedVersion describes a feature version by its update time and short content hash.
+func lockedVersion(feature *pazuzu.LockedFeature) string {
+	if feature == nil {
+		return "-"
+	}
+	hash := feature.Hash
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	if feature.UpdatedAt == "" {
+		return hash
+	}
+	return feature.UpdatedAt + " " + hash
+}
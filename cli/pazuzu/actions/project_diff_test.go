@@ -0,0 +1,91 @@
+package actions
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/mock"
+	"github.com/zalando-incubator/pazuzu/shared"
+	"github.com/zalando-incubator/pazuzu/storageconnector"
+)
+
+func TestWriteFeatureChanges(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeFeatureChanges(&buffer, []pazuzu.FeatureChange{
+		{Name: "go", Change: pazuzu.FeatureAdded, New: &pazuzu.LockedFeature{Name: "go", Hash: "0123456789abcdef"}},
+		{
+			Name:   "python",
+			Change: pazuzu.FeatureUpdated,
+			Old:    &pazuzu.LockedFeature{Name: "python", Hash: "aaa", UpdatedAt: "2017-01-01T00:00:00Z"},
+			New:    &pazuzu.LockedFeature{Name: "python", Hash: "bbb", UpdatedAt: "2017-02-01T00:00:00Z"},
+		},
+		{Name: "java", Change: pazuzu.FeatureRemoved, Old: &pazuzu.LockedFeature{Name: "java", Hash: "ccc"}},
+	})
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := `   FEATURE  OLD                       NEW
++  go       -                         0123456789ab
+~  python   2017-01-01T00:00:00Z aaa  2017-02-01T00:00:00Z bbb
+-  java     ccc                       -
+`
+	if buffer.String() != want {
+		t.Errorf("writeFeatureChanges() =\n%s\nwant\n%s", buffer.String(), want)
+	}
+}
+
+func TestDiffProjectUpstreamChanges(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pazuzu-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	python := shared.Feature{
+		Meta:    shared.FeatureMeta{Name: "python", UpdatedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Snippet: "RUN apt-get install python --yes",
+	}
+	lockfile := pazuzu.NewLockfile([]shared.Feature{python})
+	newer := python
+	newer.Meta.UpdatedAt = time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)
+	newer.Snippet = "RUN apt-get install python3 --yes"
+	registry := mock.NewFeatureStorage(newer)
+
+	generated := &pazuzu.Pazuzu{StorageReader: storageconnector.NewPinnedStorage(registry, nil, lockfile.Pinned())}
+	latest := &pazuzu.Pazuzu{StorageReader: registry}
+	for _, p := range []*pazuzu.Pazuzu{generated, latest} {
+		if err := p.Generate("ubuntu", []string{"python"}); err != nil {
+			t.Fatalf("should not fail: %s", err)
+		}
+	}
+	for _, file := range generatedFiles(directory, generated) {
+		if err := ioutil.WriteFile(file.Path, file.Contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := diffProject(directory, lockfile, generated, latest)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	for _, file := range result.Files {
+		if file.Diff != "" {
+			t.Errorf("files generated from the locked versions should not differ: %s", file.Diff)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := writeFeatureChanges(&buffer, result.Features); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if !strings.Contains(buffer.String(), "~  python   2017-01-01T00:00:00Z") ||
+		!strings.Contains(buffer.String(), "2017-02-01T00:00:00Z") {
+		t.Errorf("python should be listed as updated:\n%s", buffer.String())
+	}
+}
@@ -0,0 +1,353 @@
+package actions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/cli/pazuzu/utils"
+	"github.com/zalando-incubator/pazuzu/config"
+	"github.com/zalando-incubator/pazuzu/shared"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// generatedFile is a file generated from the project configuration.
+type generatedFile struct {
+	Path     string
+	Contents []byte
+
+	// Overwrite the file even if it was edited manually
+	Overwrite bool
+}
+
+// ProjectGenerate generates Dockerfile and test.bats without building the image, so that
+// they can be consumed by other build tools.
+func ProjectGenerate(c *cli.Context) error {
+	if c.Bool("test-spec") && !c.Bool("stdout") {
+		return errors.New("--test-spec can only be used together with --stdout")
+	}
+	if c.Bool("save-edits") && (c.Bool("check") || c.Bool("stdout")) {
+		return errors.New("--save-edits can not be used together with --check or --stdout, which do not change any files")
+	}
+
+	directory := c.String("directory")
+	err := utils.CheckDestination(directory)
+	if err != nil {
+		return fmt.Errorf("Error to access directory:%s\n%s", directory, err)
+	}
+
+	// keep stdout clean when the generated file is written there
+	progress := io.Writer(os.Stdout)
+	if c.Bool("stdout") {
+		progress = os.Stderr
+	}
+
+	p, _, files, err := generateProjectFiles(c, directory, progress)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("check") {
+		outdated, err := outdatedFiles(files)
+		if err != nil {
+			return err
+		}
+		if len(outdated) > 0 {
+			return fmt.Errorf("Generated files are out of date: %s\nRun `pazuzu project generate` to update them",
+				strings.Join(outdated, ", "))
+		}
+		fmt.Fprintln(progress, "Generated files are up to date")
+		return nil
+	}
+
+	if c.Bool("stdout") {
+		contents := p.Dockerfile
+		if c.Bool("test-spec") {
+			contents = p.TestSpec
+		}
+		_, err = os.Stdout.Write(contents)
+		return err
+	}
+
+	return writeProjectFiles(directory, p, files, c.Bool("force"), progress)
+}
+
+// generateProjectFiles generates the files of the project using the versions of features
+// recorded in its lockfile. With `--save-edits`, manual edits of the Dockerfile are moved
+// to Pazuzufile first. It fails if files copied by the features are missing in the directory.
+func generateProjectFiles(c *cli.Context, directory string, progress io.Writer) (*pazuzu.Pazuzu, *pazuzu.PazuzuFile, []generatedFile, error) {
+	pinned, err := pinnedFeatures(directory)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	withTestDependencies := c.Bool("with-test-dependencies")
+	p, pazuzuFile, err := generateProject(directory, withTestDependencies, pinned, progress)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	files := generatedFiles(directory, p)
+	err = checkCopySources(directory, p.CopySources)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if !c.Bool("save-edits") {
+		return p, pazuzuFile, files, nil
+	}
+	saved, err := saveDockerfileEdits(directory, pazuzuFile, files[0], progress)
+	if err != nil || !saved {
+		return p, pazuzuFile, files, err
+	}
+	p, pazuzuFile, err = generateProject(directory, withTestDependencies, pinned, progress)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	files = generatedFiles(directory, p)
+	files[0].Overwrite = true
+	return p, pazuzuFile, files, nil
+}
+
+// generateProject reads Pazuzufile from the directory and generates Dockerfile and test spec for it.
+// The pinned features are used instead of their registry versions.
+func generateProject(directory string, withTestDependencies bool, pinned map[string]shared.Feature, progress io.Writer) (*pazuzu.Pazuzu, *pazuzu.PazuzuFile, error) {
+	pazuzufilePath := utils.GetAbsoluteFilePath(directory, pazuzu.PazuzufileName)
+	pazuzuFile, success := utils.ReadPazuzuFile(pazuzufilePath)
+	if !success {
+		return nil, nil, fmt.Errorf("Can not read configuration: %s\n", pazuzufilePath)
+	}
+
+	storageReader, err := config.GetPinnedStorageReader(*config.GetConfig(), pinned)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error during storage setup:%s", err)
+	}
+
+	p := &pazuzu.Pazuzu{
+		StorageReader:        storageReader,
+		WithTestDependencies: withTestDependencies,
+		Output:               progress,
+		Labels:               pazuzuFile.Labels(),
+		Snippet:              pazuzuFile.Snippet,
+	}
+	err = p.Generate(pazuzuFile.Base, pazuzuFile.Features)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, pazuzuFile, nil
+}
+
+// checkCopySources fails if files copied by the features are missing in the directory, which
+// is the build context of the generated Dockerfile.
+func checkCopySources(directory string, sources []string) error {
+	var missing []string
+	for _, source := range sources {
+		path := utils.GetAbsoluteFilePath(directory, source)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			missing = append(missing, path)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Files copied by the features are missing: %s\n"+
+			"The registry does not provide them, add them to the project directory", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// pinnedFeatures returns the features recorded in the lockfile of the project.
+func pinnedFeatures(directory string) (map[string]shared.Feature, error) {
+	lockfile, err := utils.ReadLockfile(utils.GetAbsoluteFilePath(directory, pazuzu.LockfileName))
+	if err != nil {
+		return nil, err
+	}
+	return lockfile.Pinned(), nil
+}
+
+func generatedFiles(directory string, p *pazuzu.Pazuzu) []generatedFile {
+	files := []generatedFile{
+		{Path: utils.GetAbsoluteFilePath(directory, pazuzu.DockerfileName), Contents: p.Dockerfile},
+	}
+	for _, file := range p.TestFiles {
+		files = append(files, generatedFile{Path: utils.GetAbsoluteFilePath(directory, file.Filename), Contents: file.Contents})
+	}
+	return files
+}
+
+// writeProjectFiles writes the generated files and the lockfile of the project.
+func writeProjectFiles(directory string, p *pazuzu.Pazuzu, files []generatedFile, force bool, progress io.Writer) error {
+	var buffer bytes.Buffer
+	err := pazuzu.WriteLockfile(&buffer, p.Lockfile)
+	if err != nil {
+		return err
+	}
+	lockfile := generatedFile{
+		Path:     utils.GetAbsoluteFilePath(directory, pazuzu.LockfileName),
+		Contents: pazuzu.AddGeneratedHeader(buffer.Bytes()),
+	}
+	return writeGeneratedFiles(append(files, lockfile), force, progress)
+}
+
+// writeGeneratedFiles writes the files unless some of them were edited manually since they were
+// generated. Those are only overwritten if forced.
+func writeGeneratedFiles(files []generatedFile, force bool, progress io.Writer) error {
+	if !force {
+		edited, err := editedFiles(files)
+		if err != nil {
+			return err
+		}
+		if len(edited) > 0 {
+			return fmt.Errorf("Files were not generated by pazuzu or were edited manually: %s\n"+
+				"Use --force to overwrite them or --save-edits to keep the Dockerfile edits in %s",
+				strings.Join(edited, ", "), pazuzu.PazuzufileName)
+		}
+	}
+
+	for _, file := range files {
+		fmt.Fprintf(progress, "Generating %s...\n", file.Path)
+		err := utils.WriteFile(file.Path, file.Contents)
+		if err != nil {
+			return fmt.Errorf("Can not write %s\n%s", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// editedFiles returns paths of the existing files which were not generated by pazuzu
+// or were modified since. Files without header written by older versions of pazuzu
+// count as generated as long as they have the same instructions as the generated ones.
+func editedFiles(files []generatedFile) ([]string, error) {
+	var edited []string
+	for _, file := range files {
+		if file.Overwrite {
+			continue
+		}
+		contents, err := ioutil.ReadFile(file.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		generated, modified := pazuzu.CheckGenerated(contents)
+		if !generated && sameInstructions(contents, file.Contents) {
+			continue
+		}
+		if !generated || modified {
+			edited = append(edited, file.Path)
+		}
+	}
+	return edited, nil
+}
+
+// saveDockerfileEdits adds the instructions added manually to the Dockerfile to the snippet
+// of Pazuzufile. Returns whether there were any.
+func saveDockerfileEdits(directory string, pazuzuFile *pazuzu.PazuzuFile, dockerfile generatedFile, progress io.Writer) (bool, error) {
+	contents, err := ioutil.ReadFile(dockerfile.Path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if generated, modified := pazuzu.CheckGenerated(contents); generated && !modified {
+		return false, nil
+	}
+
+	edits, removed := dockerfileEdits(pazuzu.StripGeneratedHeader(contents), pazuzu.StripGeneratedHeader(dockerfile.Contents))
+	if len(removed) > 0 {
+		// the snippet can only add instructions, saving the others would run changed ones twice
+		return false, fmt.Errorf("Can not save the edits of %s, generated instructions were changed or removed:\n  %s\n"+
+			"Move the edits to the snippet in %s manually or use --force to overwrite them",
+			dockerfile.Path, strings.Join(removed, "\n  "), pazuzu.PazuzufileName)
+	}
+	if len(edits) == 0 {
+		return false, nil
+	}
+
+	snippet := strings.TrimRight(pazuzuFile.Snippet, "\n")
+	if snippet != "" {
+		snippet += "\n"
+	}
+	pazuzuFile.Snippet = snippet + strings.Join(edits, "\n") + "\n"
+
+	pazuzufilePath := utils.GetAbsoluteFilePath(directory, pazuzu.PazuzufileName)
+	fmt.Fprintf(progress, "Saving edits of %s to %s...\n", dockerfile.Path, pazuzufilePath)
+	return true, utils.WritePazuzuFile(pazuzufilePath, pazuzuFile)
+}
+
+// dockerfileEdits compares the instructions of the edited Dockerfile to the generated one.
+// It returns the ones added manually and the generated ones which were changed or removed.
+func dockerfileEdits(edited []byte, generated []byte) (added []string, removed []string) {
+	editedLines := instructionLines(edited)
+	generatedLines := instructionLines(generated)
+
+	known := map[string]bool{}
+	for _, line := range generatedLines {
+		known[strings.TrimSpace(line)] = true
+	}
+	kept := map[string]bool{}
+	for _, line := range editedLines {
+		trimmed := strings.TrimSpace(line)
+		kept[trimmed] = true
+		if !known[trimmed] {
+			added = append(added, line)
+		}
+	}
+	for _, line := range generatedLines {
+		if !kept[strings.TrimSpace(line)] {
+			removed = append(removed, strings.TrimSpace(line))
+		}
+	}
+	return added, removed
+}
+
+// sameInstructions reports whether the files have the same lines, ignoring empty lines,
+// comments and indentation.
+func sameInstructions(contents []byte, other []byte) bool {
+	lines := instructionLines(contents)
+	otherLines := instructionLines(other)
+	if len(lines) != len(otherLines) {
+		return false
+	}
+	for i := range lines {
+		if strings.TrimSpace(lines[i]) != strings.TrimSpace(otherLines[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// instructionLines returns the lines of the Dockerfile which are neither empty nor comments.
+func instructionLines(dockerfile []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(dockerfile), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// outdatedFiles returns paths of the files which are missing or differ from the generated contents.
+func outdatedFiles(files []generatedFile) ([]string, error) {
+	var outdated []string
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file.Path)
+		if os.IsNotExist(err) {
+			outdated = append(outdated, file.Path)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(contents, file.Contents) {
+			outdated = append(outdated, file.Path)
+		}
+	}
+	return outdated, nil
+}
@@ -0,0 +1,126 @@
+package actions
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu"
+)
+
+func TestOutdatedFiles(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pazuzu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	upToDate := filepath.Join(directory, "Dockerfile")
+	changed := filepath.Join(directory, "test.bats")
+	missing := filepath.Join(directory, "missing")
+	ioutil.WriteFile(upToDate, []byte("FROM ubuntu\n"), 0644)
+	ioutil.WriteFile(changed, []byte("old"), 0644)
+
+	outdated, err := outdatedFiles([]generatedFile{
+		{Path: upToDate, Contents: []byte("FROM ubuntu\n")},
+		{Path: changed, Contents: []byte("new")},
+		{Path: missing, Contents: []byte("new")},
+	})
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if want := []string{changed, missing}; !reflect.DeepEqual(outdated, want) {
+		t.Errorf("outdatedFiles() = %v, want %v", outdated, want)
+	}
+}
+
+func TestEditedFiles(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pazuzu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	generated := pazuzu.AddGeneratedHeader([]byte("FROM ubuntu\n"))
+	files := map[string][]byte{
+		"generated": generated,
+		"edited":    append(append([]byte{}, generated...), "RUN make\n"...),
+		"manual":    []byte("FROM ubuntu\n"),
+		"forced":    []byte("FROM ubuntu\n"),
+		"legacy":    []byte("# written without header\nFROM ubuntu\n"),
+	}
+	var generatedFiles []generatedFile
+	for _, name := range []string{"generated", "edited", "manual", "forced", "legacy", "missing"} {
+		path := filepath.Join(directory, name)
+		if contents, ok := files[name]; ok {
+			ioutil.WriteFile(path, contents, 0644)
+		}
+		file := generatedFile{Path: path, Overwrite: name == "forced"}
+		if name == "legacy" {
+			file.Contents = generated
+		}
+		generatedFiles = append(generatedFiles, file)
+	}
+
+	edited, err := editedFiles(generatedFiles)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	want := []string{filepath.Join(directory, "edited"), filepath.Join(directory, "manual")}
+	if !reflect.DeepEqual(edited, want) {
+		t.Errorf("editedFiles() = %v, want %v", edited, want)
+	}
+
+	err = writeGeneratedFiles(generatedFiles, false, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("edited files should not be overwritten: %v", err)
+	}
+}
+
+func TestDockerfileEdits(t *testing.T) {
+	generated := "FROM ubuntu\n\n# node\nRUN install node\n# default command\nCMD /bin/bash\n"
+
+	tests := []struct {
+		name    string
+		edited  string
+		added   []string
+		removed []string
+	}{
+		{"Added instructions", "FROM ubuntu\n\n# node\nRUN install node\n# my tools\nRUN install make\n  ENV A b\nCMD /bin/bash\n",
+			[]string{"RUN install make", "  ENV A b"}, nil},
+		{"Changed instruction", "FROM ubuntu\n\n# node\nRUN install node@6\nCMD /bin/bash\n",
+			[]string{"RUN install node@6"}, []string{"RUN install node"}},
+		{"Removed instruction", "FROM ubuntu\nCMD /bin/bash\n", nil, []string{"RUN install node"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := dockerfileEdits([]byte(tt.edited), []byte(generated))
+			if !reflect.DeepEqual(added, tt.added) || !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("dockerfileEdits() = %q, %q, want %q, %q", added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
+
+func TestCheckCopySources(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pazuzu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	os.MkdirAll(filepath.Join(directory, "java"), 0755)
+	ioutil.WriteFile(filepath.Join(directory, "java", "lib.jar"), []byte("jar"), 0644)
+
+	if err := checkCopySources(directory, []string{"java/lib.jar"}); err != nil {
+		t.Errorf("existing sources should be accepted: %s", err)
+	}
+	err = checkCopySources(directory, []string{"java/lib.jar", "node/npmrc"})
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(directory, "node", "npmrc")) {
+		t.Errorf("missing source should be named: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package actions
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/cli/pazuzu/utils"
+	"github.com/zalando-incubator/pazuzu/config"
+	"github.com/zalando-incubator/pazuzu/shared"
+	storage "github.com/zalando-incubator/pazuzu/storageconnector"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProjectInit creates a new Pazuzufile with the given base image and features.
+// When no settings are given and a terminal is attached, the user is asked for them.
+func ProjectInit(c *cli.Context) error {
+	destination := c.String("directory")
+	err := utils.CheckDestination(destination)
+	if err != nil {
+		return err
+	}
+
+	pazuzufilePath := utils.GetAbsoluteFilePath(destination, pazuzu.PazuzufileName)
+	if _, err := os.Stat(pazuzufilePath); err == nil && !c.Bool("force") {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", pazuzufilePath)
+	}
+
+	base := c.String("base")
+	featuresToInit := getFeaturesList(strings.Join(c.Args(), ","))
+
+	interactive := c.Bool("interactive") || (base == "" && len(featuresToInit) == 0 && isTerminal(os.Stdin))
+	if interactive {
+		storageReader, err := config.GetStorageReader(*config.GetConfig())
+		if err != nil {
+			return fmt.Errorf("Error during storage setup:%s", err)
+		}
+
+		pazuzuFile, err := promptPazuzuFile(os.Stdin, os.Stdout, config.GetConfig().Base, storageReader)
+		if err != nil {
+			return err
+		}
+		base = pazuzuFile.Base
+		featuresToInit = pazuzuFile.Features
+	}
+
+	features, err := utils.GenerateFeaturesList(nil, featuresToInit, nil)
+	if err != nil {
+		return err
+	}
+
+	return generateFiles(destination, &pazuzu.PazuzuFile{Base: base, Features: features})
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// promptPazuzuFile walks the user through choosing a base image and searching for features.
+func promptPazuzuFile(in io.Reader, out io.Writer, defaultBase string, reader storage.StorageReader) (*pazuzu.PazuzuFile, error) {
+	scanner := bufio.NewScanner(in)
+	prompt := func(message string) (string, error) {
+		fmt.Fprint(out, message)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		return strings.TrimSpace(scanner.Text()), nil
+	}
+
+	pazuzuFile := &pazuzu.PazuzuFile{Base: defaultBase}
+
+	base, err := prompt(fmt.Sprintf("Base image [%s]: ", defaultBase))
+	if err != nil {
+		return nil, err
+	}
+	if base != "" {
+		pazuzuFile.Base = base
+	}
+
+	for {
+		query, err := prompt("Search features (empty to finish): ")
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if query == "" {
+			break
+		}
+
+		found, err := SearchHandler(query, reader)
+		if err != nil || len(found) == 0 {
+			found, _ = FuzzySearchHandler(query, shared.SearchParams{}, reader)
+		}
+		if len(found) == 0 {
+			fmt.Fprintln(out, "No features found")
+			continue
+		}
+		for i, meta := range found {
+			fmt.Fprintf(out, "%3d) %s - %s\n", i+1, meta.Name, meta.Description)
+		}
+
+		selection, err := prompt("Select features (comma separated numbers, empty to skip): ")
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		for _, choice := range getFeaturesList(selection) {
+			index, errIndex := strconv.Atoi(choice)
+			if errIndex != nil || index < 1 || index > len(found) {
+				fmt.Fprintf(out, "Skipping invalid choice: %s\n", choice)
+				continue
+			}
+			if !isFeatureInList(pazuzuFile.Features, found[index-1].Name) {
+				pazuzuFile.Features = append(pazuzuFile.Features, found[index-1].Name)
+			}
+		}
+		fmt.Fprintf(out, "Selected features: %s\n", strings.Join(pazuzuFile.Features, ", "))
+	}
+
+	if pazuzuFile.Base == "" {
+		return nil, errors.New("Base image is not provided")
+	}
+	return pazuzuFile, nil
+}
@@ -0,0 +1,42 @@
+package actions
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu/mock"
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+func TestPromptPazuzuFile(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node"}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "java"}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "javascript-tools"}},
+	)
+
+	tests := []struct {
+		name     string
+		input    string
+		base     string
+		features []string
+	}{
+		{"Defaults", "\n\n", "ubuntu:14.04", nil},
+		{"Base and features", "debian\njava\n1,2,7\nnodjs\n1\n\n", "debian", []string{"java", "javascript-tools", "node"}},
+		{"End of input", "alpine\nnode\n", "alpine", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			pazuzuFile, err := promptPazuzuFile(strings.NewReader(tt.input), &out, "ubuntu:14.04", storage)
+			if err != nil {
+				t.Fatalf("should not fail: %s", err)
+			}
+			if pazuzuFile.Base != tt.base || !reflect.DeepEqual(pazuzuFile.Features, tt.features) {
+				t.Errorf("promptPazuzuFile() = %v, want %s %v\n%s", pazuzuFile, tt.base, tt.features, out.String())
+			}
+		})
+	}
+}
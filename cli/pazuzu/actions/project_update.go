@@ -0,0 +1,107 @@
+package actions
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli"
+	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/cli/pazuzu/utils"
+	"io"
+	"os"
+	"strings"
+)
+
+// ProjectUpdate picks up new versions of all or the given features from the registry.
+// Features which changed since the last generation are listed and the generated files
+// are rewritten with the accepted changes only.
+func ProjectUpdate(c *cli.Context) error {
+	directory := c.String("directory")
+	err := utils.CheckDestination(directory)
+	if err != nil {
+		return fmt.Errorf("Error to access directory:%s\n%s", directory, err)
+	}
+
+	lockfilePath := utils.GetAbsoluteFilePath(directory, pazuzu.LockfileName)
+	lockfile, err := utils.ReadLockfile(lockfilePath)
+	if err != nil {
+		return err
+	}
+	if len(lockfile.Features) == 0 {
+		return fmt.Errorf("%s not found, run `pazuzu project generate` first", lockfilePath)
+	}
+
+	withTestDependencies := c.Bool("with-test-dependencies")
+	latest, _, err := generateProject(directory, withTestDependencies, nil, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	changes := upstreamChanges(lockfile, latest.Lockfile, getFeaturesList(strings.Join(c.Args(), ",")))
+	if len(changes) == 0 {
+		fmt.Println("All features are up to date")
+		return nil
+	}
+	err = writeFeatureChanges(os.Stdout, changes)
+	if err != nil || c.Bool("dry-run") {
+		return err
+	}
+
+	accepted := changes
+	if !c.Bool("yes") {
+		if !isTerminal(os.Stdin) {
+			return errors.New("Use --yes to accept the changes without a terminal")
+		}
+		accepted, err = confirmChanges(os.Stdin, os.Stdout, changes)
+		if err != nil {
+			return err
+		}
+	}
+	if len(accepted) == 0 {
+		fmt.Println("No changes accepted")
+		return nil
+	}
+
+	pinned := lockfile.Pinned()
+	for _, change := range accepted {
+		delete(pinned, change.Name)
+	}
+	p, _, err := generateProject(directory, withTestDependencies, pinned, os.Stdout)
+	if err != nil {
+		return err
+	}
+	return writeProjectFiles(directory, p, generatedFiles(directory, p), c.Bool("force"), os.Stdout)
+}
+
+// upstreamChanges returns the locked features which were updated in the registry,
+// limited to the given names if there are any.
+func upstreamChanges(locked pazuzu.Lockfile, latest pazuzu.Lockfile, names []string) []pazuzu.FeatureChange {
+	var changes []pazuzu.FeatureChange
+	for _, change := range pazuzu.DiffLockfiles(locked, latest) {
+		if change.Change != pazuzu.FeatureUpdated {
+			continue
+		}
+		if len(names) > 0 && !isFeatureInList(names, change.Name) {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// confirmChanges asks whether to accept every change and returns the accepted ones.
+func confirmChanges(in io.Reader, out io.Writer, changes []pazuzu.FeatureChange) ([]pazuzu.FeatureChange, error) {
+	scanner := bufio.NewScanner(in)
+	var accepted []pazuzu.FeatureChange
+	for _, change := range changes {
+		fmt.Fprintf(out, "Update %s? [y/N]: ", change.Name)
+		if !scanner.Scan() {
+			break
+		}
+		answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if answer == "y" || answer == "yes" {
+			accepted = append(accepted, change)
+		}
+	}
+	return accepted, scanner.Err()
+}
@@ -0,0 +1,57 @@
+package actions
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu"
+)
+
+func TestUpstreamChanges(t *testing.T) {
+	locked := pazuzu.Lockfile{Features: []pazuzu.LockedFeature{
+		{Name: "java", Hash: "1"},
+		{Name: "node", Hash: "2"},
+		{Name: "python", Hash: "3"},
+	}}
+	latest := pazuzu.Lockfile{Features: []pazuzu.LockedFeature{
+		{Name: "java", Hash: "1"},
+		{Name: "node", Hash: "22"},
+		{Name: "python", Hash: "33"},
+		{Name: "go", Hash: "4"},
+	}}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{"All features", nil, []string{"node", "python"}},
+		{"Selected features", []string{"python", "java"}, []string{"python"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range upstreamChanges(locked, latest, tt.names) {
+				got = append(got, change.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("upstreamChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmChanges(t *testing.T) {
+	changes := []pazuzu.FeatureChange{{Name: "node"}, {Name: "python"}, {Name: "java"}}
+
+	var out bytes.Buffer
+	accepted, err := confirmChanges(strings.NewReader("y\nn\nYes\n"), &out, changes)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if len(accepted) != 2 || accepted[0].Name != "node" || accepted[1].Name != "java" {
+		t.Errorf("confirmChanges() = %v, want node and java", accepted)
+	}
+}
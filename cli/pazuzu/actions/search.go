@@ -10,24 +10,49 @@ import (
 	"os"
 	"regexp"
 	"text/tabwriter"
+	"time"
 )
 
 func Search(c *cli.Context) error {
-	if c.NArg() != 1 {
-		return errors.New("Search regexp is not provided")
+	if c.NArg() > 1 {
+		return errors.New("Wrong number of arguments")
 	}
 	featureName := c.Args().First()
+	output, err := outputFormat(c)
+	if err != nil {
+		return err
+	}
+	params, err := searchParams(c)
+	if err != nil {
+		return err
+	}
 	cfg := config.GetConfig()
 	storage, err := config.GetStorageReader(*cfg)
 	if err != nil {
 		return errors.New("Can't create storage reader")
 	}
 
-	features, err := SearchHandler(featureName, storage)
+	var features []shared.FeatureMeta
+	if c.Bool("fuzzy") {
+		if featureName == "" {
+			return errors.New("Search query is not provided")
+		}
+		features, err = FuzzySearchHandler(featureName, params, storage)
+	} else {
+		features, err = FilteredSearchHandler(featureName, params, storage)
+	}
 	if err != nil {
 		return errors.New("Can't execute search request")
 	}
 
+	if output != OutputTable {
+		summaries := []FeatureSummary{}
+		for _, f := range features {
+			summaries = append(summaries, NewFeatureSummary(f))
+		}
+		return writeStructured(os.Stdout, output, summaries)
+	}
+
 	if len(features) == 0 {
 		fmt.Println("No features found")
 		return nil
@@ -42,16 +67,89 @@ func Search(c *cli.Context) error {
 	return nil
 }
 
-func SearchHandler(feature string, storage storage.StorageReader) ([]shared.FeatureMeta, error) {
+func SearchHandler(feature string, reader storage.StorageReader) ([]shared.FeatureMeta, error) {
+	return FilteredSearchHandler(feature, shared.SearchParams{}, reader)
+}
+
+// FilteredSearchHandler searches for features with names matching the regexp and all the params.
+func FilteredSearchHandler(feature string, params shared.SearchParams, reader storage.StorageReader) ([]shared.FeatureMeta, error) {
 	featureRegexp, err := regexp.Compile(feature)
 	if err != nil {
 		return nil, errors.New("Can't compile search regexp")
 	}
+	params.Name = featureRegexp
+
+	features, err := reader.Search(params)
+	if err != nil {
+		return nil, errors.New("Can't execute search request")
+	}
+
+	return features, nil
+}
 
-	features, err := storage.SearchMeta(featureRegexp)
+// FuzzySearchHandler searches for features with names similar to the query and matching all the params.
+// The features are ordered by similarity to the query.
+func FuzzySearchHandler(query string, params shared.SearchParams, reader storage.StorageReader) ([]shared.FeatureMeta, error) {
+	limit := params.Limit
+	params.Name = nil
+	params.Limit = 0
+
+	metas, err := reader.Search(params)
 	if err != nil {
 		return nil, errors.New("Can't execute search request")
 	}
 
+	byName := map[string]shared.FeatureMeta{}
+	var names []string
+	for _, meta := range metas {
+		byName[meta.Name] = meta
+		names = append(names, meta.Name)
+	}
+
+	features := []shared.FeatureMeta{}
+	for _, name := range shared.FuzzyFind(query, names) {
+		if limit > 0 && len(features) == limit {
+			break
+		}
+		features = append(features, byName[name])
+	}
 	return features, nil
 }
+
+func searchParams(c *cli.Context) (shared.SearchParams, error) {
+	params := shared.SearchParams{
+		Author:      c.String("author"),
+		Description: c.String("description"),
+		DependsOn:   c.String("depends-on"),
+		SortBy:      c.String("sort"),
+		Limit:       c.Int("limit"),
+	}
+
+	if params.SortBy == "" {
+		params.SortBy = shared.SortByName
+	}
+	if params.SortBy != shared.SortByName && params.SortBy != shared.SortByUpdatedAt {
+		return params, fmt.Errorf("Unknown sort order: %s", params.SortBy)
+	}
+	if params.Limit < 0 {
+		return params, fmt.Errorf("Invalid limit: %d", params.Limit)
+	}
+
+	if since := c.String("updated-since"); since != "" {
+		updatedSince, err := parseDate(since)
+		if err != nil {
+			return params, fmt.Errorf("Invalid date: %s", since)
+		}
+		params.UpdatedSince = updatedSince
+	}
+	return params, nil
+}
+
+// parseDate accepts a date (2017-03-01) or a full RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", value)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
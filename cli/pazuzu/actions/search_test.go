@@ -3,6 +3,7 @@ package actions
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/zalando-incubator/pazuzu/mock"
 	"github.com/zalando-incubator/pazuzu/shared"
@@ -39,3 +40,47 @@ func TestSearchHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestFilteredSearchHandler(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "java", Author: "John"}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "maven", Author: "Jane", Dependencies: []string{"java"}}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node", Author: "Jane"}},
+	)
+
+	got, err := FilteredSearchHandler("a", shared.SearchParams{DependsOn: "java"}, storage)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if len(got) != 1 || got[0].Name != "maven" {
+		t.Errorf("FilteredSearchHandler() = %v, want [maven]", got)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	for _, value := range []string{"2017-03-01", "2017-03-01T00:00:00Z"} {
+		date, err := parseDate(value)
+		if err != nil || !date.Equal(time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("parseDate(%s) = %v, %v", value, date, err)
+		}
+	}
+	if _, err := parseDate("yesterday"); err == nil {
+		t.Error("parseDate() should fail on invalid date")
+	}
+}
+
+func TestFuzzySearchHandler(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node"}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node-8"}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "java"}},
+	)
+
+	got, err := FuzzySearchHandler("nodejs", shared.SearchParams{Limit: 1}, storage)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if len(got) != 1 || got[0].Name != "node" {
+		t.Errorf("FuzzySearchHandler() = %v, want [node]", got)
+	}
+}
@@ -2,12 +2,14 @@ package command
 
 import (
 	"github.com/zalando-incubator/pazuzu/cli/pazuzu/command/config"
+	"github.com/zalando-incubator/pazuzu/cli/pazuzu/command/feature"
 	"github.com/zalando-incubator/pazuzu/cli/pazuzu/command/project"
 	"github.com/zalando-incubator/pazuzu/cli/pazuzu/command/search"
 )
 
 var (
 	Config  = config.Command
+	Feature = feature.Command
 	Project = project.Command
 	Search  = search.Command
 )
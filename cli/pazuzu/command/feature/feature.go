@@ -0,0 +1,45 @@
+package feature
+
+import (
+	"github.com/urfave/cli"
+	"github.com/zalando-incubator/pazuzu/cli/pazuzu/actions"
+)
+
+var Command = cli.Command{
+	Name:  "feature",
+	Usage: "Inspect features available in registry",
+	Subcommands: []cli.Command{
+		{
+			Name:      "tree",
+			Usage:     "Show resolved dependency tree of the project or the given features",
+			ArgsUsage: "[features] - comma separated features, the project features are used if omitted",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "d, directory",
+					Usage: "Sets source path where project configuration is located.",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Format of the tree: tree or dot (Graphviz)",
+				},
+				cli.StringFlag{
+					Name:  "why",
+					Usage: "Show every dependency path which pulls in the given feature",
+				},
+			},
+			Action: actions.FeatureTree,
+		},
+		{
+			Name:      "info",
+			Usage:     "Show all details of a feature",
+			ArgsUsage: "[name] - name of the feature",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "o, output",
+					Usage: "Output format: table, json or yaml",
+				},
+			},
+			Action: actions.FeatureInfo,
+		},
+	},
+}
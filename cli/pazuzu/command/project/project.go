@@ -15,6 +15,26 @@ var Command = cli.Command{
 		},
 	},
 	Subcommands: []cli.Command{
+		{
+			Name:      "init",
+			Usage:     "Create Pazuzufile with the given base image and features",
+			ArgsUsage: "[features] - comma separated features",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "b, base",
+					Usage: "Set the base image, the configured one is used by default",
+				},
+				cli.BoolFlag{
+					Name:  "i, interactive",
+					Usage: "Ask for the base image and features",
+				},
+				cli.BoolFlag{
+					Name:  "f, force",
+					Usage: "Overwrite an existing Pazuzufile",
+				},
+			},
+			Action: actions.ProjectInit,
+		},
 		{
 			Name:   "add",
 			Usage:  "Add feature to the project",
@@ -31,8 +51,14 @@ var Command = cli.Command{
 			Action: actions.ProjectRemoveFeatures,
 		},
 		{
-			Name:   "clean",
-			Usage:  "Remove all Pazuzu-generated files",
+			Name:  "clean",
+			Usage: "Remove all Pazuzu-generated files",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "f, force",
+					Usage: "Remove Pazuzufile and files edited manually too",
+				},
+			},
 			Action: actions.ProjectClean,
 		},
 		{
@@ -43,18 +69,146 @@ var Command = cli.Command{
 					Name:  "n, name",
 					Usage: "Set the name for Docker image",
 				},
+				cli.BoolFlag{
+					Name:  "f, force",
+					Usage: "Overwrite files edited manually",
+				},
+				cli.BoolFlag{
+					Name:  "save-edits",
+					Usage: "Keep manual edits of the Dockerfile as snippet in Pazuzufile",
+				},
+				cli.BoolFlag{
+					Name:  "with-test-dependencies",
+					Usage: "Install test-only dependencies into the Docker image",
+				},
+				cli.StringSliceFlag{
+					Name:  "report",
+					Usage: "Write test results to a JUnit XML file, or JSON if the file name ends with .json",
+				},
+				cli.StringFlag{
+					Name:  "timeout",
+					Usage: "Kill the tests of a feature running longer (ex: 10m), overrides the test_timeout setting",
+				},
+				cli.BoolFlag{
+					Name:  "keep-container",
+					Usage: "Leave the test container for debugging if tests fail",
+				},
+				cli.BoolFlag{
+					Name:  "q, quiet",
+					Usage: "Show only errors of the image build",
+				},
 			},
 			Action: actions.ProjectBuild,
 		},
 		{
-			Name:   "show",
-			Usage:  "Show base image, author, license settings of the project",
-			Action: actions.ProjectShow,
+			Name:      "test",
+			Usage:     "Test an existing Docker image against the project features",
+			ArgsUsage: "image - name or ID of the image",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "features",
+					Usage: "Comma separated features to run the tests of, all by default",
+				},
+				cli.BoolFlag{
+					Name:  "with-test-dependencies",
+					Usage: "Test-only dependencies are installed in the image already",
+				},
+				cli.StringSliceFlag{
+					Name:  "report",
+					Usage: "Write test results to a JUnit XML file, or JSON if the file name ends with .json",
+				},
+				cli.StringFlag{
+					Name:  "timeout",
+					Usage: "Kill the tests of a feature running longer (ex: 10m), overrides the test_timeout setting",
+				},
+				cli.BoolFlag{
+					Name:  "keep-container",
+					Usage: "Leave the test container for debugging if tests fail",
+				},
+				cli.BoolFlag{
+					Name:  "q, quiet",
+					Usage: "Show only errors of the image build",
+				},
+			},
+			Action: actions.ProjectTest,
+		},
+		{
+			Name:  "generate",
+			Usage: "Generate Dockerfile and test.bats without building the image",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "stdout",
+					Usage: "Write the Dockerfile to stdout instead of the project directory",
+				},
+				cli.BoolFlag{
+					Name:  "test-spec",
+					Usage: "Write test.bats instead of the Dockerfile to stdout, requires --stdout",
+				},
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: "Fail if the generated files in the project directory are out of date",
+				},
+				cli.BoolFlag{
+					Name:  "f, force",
+					Usage: "Overwrite files edited manually",
+				},
+				cli.BoolFlag{
+					Name:  "save-edits",
+					Usage: "Keep manual edits of the Dockerfile as snippet in Pazuzufile",
+				},
+				cli.BoolFlag{
+					Name:  "with-test-dependencies",
+					Usage: "Install test-only dependencies into the Docker image",
+				},
+			},
+			Action: actions.ProjectGenerate,
+		},
+		{
+			Name:  "diff",
+			Usage: "Show changes regenerating the project would make",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "with-test-dependencies",
+					Usage: "Install test-only dependencies into the Docker image",
+				},
+			},
+			Action: actions.ProjectDiff,
+		},
+		{
+			Name:      "update",
+			Usage:     "Update features to their latest versions in the registry",
+			ArgsUsage: "[features] - comma separated features, all by default",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "y, yes",
+					Usage: "Accept all the updates without asking",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Only show the updated features",
+				},
+				cli.BoolFlag{
+					Name:  "f, force",
+					Usage: "Overwrite files edited manually",
+				},
+				cli.BoolFlag{
+					Name:  "with-test-dependencies",
+					Usage: "Install test-only dependencies into the Docker image",
+				},
+			},
+			Action: actions.ProjectUpdate,
+		},
+		{
+			Name:      "show",
+			Usage:     "Show base image, author, license settings of the project",
+			ArgsUsage: "[key] - all settings are shown by default",
+			Action:    actions.ProjectShow,
 		},
 		{
-			Name:   "set",
-			Usage:  "Set base image, author, license settings of the project",
-			Action: actions.ProjectSet,
+			Name:      "set",
+			Usage:     "Set base image, author, license settings of the project",
+			ArgsUsage: "key value - lists like maintainers are comma separated",
+			Action:    actions.ProjectSet,
 		},
 	},
 }
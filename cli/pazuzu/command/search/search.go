@@ -9,5 +9,36 @@ var Command = cli.Command{
 	Name:      "search",
 	Usage:     "Search for features in registry",
 	ArgsUsage: "[query] - query to be used for feature lookup for substring search in features names",
-	Action:    actions.Search,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "author",
+			Usage: "Show only features whose author contains the given text",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "Show only features whose description contains the given text",
+		},
+		cli.StringFlag{
+			Name:  "depends-on",
+			Usage: "Show only features depending directly on the given feature",
+		},
+		cli.StringFlag{
+			Name:  "updated-since",
+			Usage: "Show only features updated since the given date (ex: '2017-03-01')",
+		},
+		cli.StringFlag{
+			Name:  "sort",
+			Value: "name",
+			Usage: "Sort features by 'name' or 'updated' (most recent first)",
+		},
+		cli.IntFlag{
+			Name:  "limit",
+			Usage: "Show at most the given number of features",
+		},
+		cli.BoolFlag{
+			Name:  "fuzzy",
+			Usage: "Find features with names similar to the query, the most similar first",
+		},
+	},
+	Action: actions.Search,
 }
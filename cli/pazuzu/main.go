@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/urfave/cli"
 	"github.com/zalando-incubator/pazuzu/cli/pazuzu/command"
+	"github.com/zalando-incubator/pazuzu/cli/pazuzu/utils"
 	"github.com/zalando-incubator/pazuzu/config"
 	"io/ioutil"
 	"log"
@@ -26,6 +27,7 @@ func main() {
 	app.Usage = "Build Docker features from pazuzu-registry"
 	app.Commands = []cli.Command{
 		command.Config,
+		command.Feature,
 		command.Project,
 		command.Search,
 	}
@@ -36,6 +38,11 @@ func main() {
 			Name:  "verbose, v",
 			Usage: "Verbose output",
 		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Value: "table",
+			Usage: "Output format: table, json or yaml",
+		},
 	}
 	app.Before = func(c *cli.Context) error {
 		// remove formatting for log module
@@ -53,13 +60,14 @@ func main() {
 			fmt.Println(errCnf)
 			os.Exit(1)
 		}
+		utils.KeepBackups = config.GetConfig().Backup
 
 		return nil
 	}
 
 	err := app.Run(os.Args)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Number of unchanged lines shown around the changes
+const diffContext = 3
+
+type diffLine struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// UnifiedDiff returns the unified diff between the old and the new text,
+// or an empty string if they are equal.
+func UnifiedDiff(oldName string, newName string, old []byte, new []byte) string {
+	lines := diffLines(splitLines(old), splitLines(new))
+
+	// line numbers of both texts before every diff line
+	oldPos := make([]int, len(lines)+1)
+	newPos := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if line.kind != '+' {
+			oldPos[i+1]++
+		}
+		if line.kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	var buffer bytes.Buffer
+	for next := 0; next < len(lines); {
+		first := next
+		for first < len(lines) && lines[first].kind == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first + 1; i < len(lines) && i-last <= 2*diffContext; i++ {
+			if lines[i].kind != ' ' {
+				last = i
+			}
+		}
+
+		from := maxInt(first-diffContext, 0)
+		to := minInt(last+diffContext+1, len(lines))
+		if buffer.Len() == 0 {
+			fmt.Fprintf(&buffer, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		fmt.Fprintf(&buffer, "@@ -%s +%s @@\n",
+			hunkRange(oldPos[from], oldPos[to]-oldPos[from]), hunkRange(newPos[from], newPos[to]-newPos[from]))
+		for _, line := range lines[from:to] {
+			fmt.Fprintf(&buffer, "%c%s\n", line.kind, line.text)
+		}
+		next = to
+	}
+	return buffer.String()
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(text []byte) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(text), "\n"), "\n")
+}
+
+// diffLines computes the shortest edit script between two lists of lines
+// based on their longest common subsequence.
+func diffLines(a []string, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = maxInt(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// KeepBackups enables keeping the previous version of an overwritten file as <file>.bak
+var KeepBackups = false
+
+// Checksums of the file contents last read or written through ReadFile and WriteFile, by
+// absolute path. They are used to detect modifications made by somebody else in the meantime.
+var (
+	knownContents     = map[string][sha256.Size]byte{}
+	knownContentsLock sync.Mutex
+)
+
+// ReadFile reads the file and remembers its content, so that WriteFile refuses to
+// overwrite it if it was modified in the meantime, see checkUnmodified.
+func ReadFile(path string) ([]byte, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rememberContents(path, contents)
+	return contents, nil
+}
+
+// WriteFile replaces the file atomically: the contents are written to a temporary file
+// in the same directory, which is renamed to the target afterwards. Permissions of an
+// existing file are preserved, new files get the default ones limited by the umask.
+func WriteFile(path string, contents []byte) error {
+	mode := os.FileMode(0666)
+	info, err := os.Stat(path)
+	exists := err == nil
+	if exists {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	temp, err := createTemp(path, mode)
+	if err != nil {
+		return fmt.Errorf("Could not create %v: %v", path, err)
+	}
+	// does nothing once the file is renamed
+	defer os.Remove(temp.Name())
+
+	_, err = temp.Write(contents)
+	if err == nil && exists {
+		// the umask applies to the created file, the existing permissions are restored exactly
+		err = temp.Chmod(mode)
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	if errClose := temp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return fmt.Errorf("Could not write %v: %v", path, err)
+	}
+
+	// checked as late as possible to keep the window for concurrent modifications small
+	err = checkUnmodified(path)
+	if err != nil {
+		return err
+	}
+	if exists && KeepBackups {
+		err = backupFile(path, mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.Rename(temp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("Could not replace %v: %v", path, err)
+	}
+	syncDir(filepath.Dir(path))
+	rememberContents(path, contents)
+	return nil
+}
+
+// createTemp creates a new temporary file next to the path with the given permissions,
+// which are limited by the umask.
+func createTemp(path string, mode os.FileMode) (*os.File, error) {
+	for i := 0; ; i++ {
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, err
+		}
+		name := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+"."+hex.EncodeToString(suffix))
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, mode)
+		if os.IsExist(err) && i < 100 {
+			continue
+		}
+		return file, err
+	}
+}
+
+// syncDir makes the rename of a file in the directory durable. Not every platform is able
+// to sync directories, so it is done on a best effort basis.
+func syncDir(directory string) {
+	dir, err := os.Open(directory)
+	if err != nil {
+		return
+	}
+	dir.Sync()
+	dir.Close()
+}
+
+func backupFile(path string, mode os.FileMode) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path+".bak", contents, mode)
+	if err != nil {
+		return fmt.Errorf("Could not back up %v: %v", path, err)
+	}
+	return nil
+}
+
+func rememberContents(path string, contents []byte) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	knownContentsLock.Lock()
+	defer knownContentsLock.Unlock()
+	knownContents[absolute] = sha256.Sum256(contents)
+}
+
+// checkUnmodified fails if the file was changed since it was read by ReadFile or written by
+// WriteFile. Files never read or written this way are not checked, and a modification
+// between this check and the rename of WriteFile is not detected.
+func checkUnmodified(path string) error {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	knownContentsLock.Lock()
+	known, ok := knownContents[absolute]
+	knownContentsLock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if os.IsNotExist(err) || sha256.Sum256(contents) != known {
+		return fmt.Errorf("%v was modified by another process, not overwriting it", path)
+	}
+	return nil
+}
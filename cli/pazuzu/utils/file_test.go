@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempDir(t *testing.T) string {
+	directory, err := ioutil.TempDir("", "pazuzu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return directory
+}
+
+func TestWriteFile(t *testing.T) {
+	directory := tempDir(t)
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "Dockerfile")
+
+	t.Run("Create a file", func(t *testing.T) {
+		if err := WriteFile(path, []byte("FROM ubuntu\n")); err != nil {
+			t.Fatalf("should not fail: %s", err)
+		}
+		contents, _ := ioutil.ReadFile(path)
+		if string(contents) != "FROM ubuntu\n" {
+			t.Errorf("wrong contents: %s", contents)
+		}
+
+		// a file created the usual way gets the permissions allowed by the umask
+		reference := filepath.Join(tempDir(t), "reference")
+		defer os.RemoveAll(filepath.Dir(reference))
+		ioutil.WriteFile(reference, nil, 0666)
+		info, _ := os.Stat(path)
+		want, _ := os.Stat(reference)
+		if info.Mode().Perm() != want.Mode().Perm() {
+			t.Errorf("permissions = %v, want %v", info.Mode().Perm(), want.Mode().Perm())
+		}
+	})
+
+	t.Run("Preserve permissions and keep a backup", func(t *testing.T) {
+		os.Chmod(path, 0600)
+		KeepBackups = true
+		defer func() { KeepBackups = false }()
+
+		if err := WriteFile(path, []byte("FROM debian\n")); err != nil {
+			t.Fatalf("should not fail: %s", err)
+		}
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("permissions should be preserved: %v", info.Mode())
+		}
+		backup, _ := ioutil.ReadFile(path + ".bak")
+		if string(backup) != "FROM ubuntu\n" {
+			t.Errorf("wrong backup: %s", backup)
+		}
+	})
+
+	t.Run("No temporary files are left", func(t *testing.T) {
+		files, _ := ioutil.ReadDir(directory)
+		if len(files) != 2 {
+			t.Errorf("only the file and its backup should exist: %v", files)
+		}
+	})
+}
+
+func TestWriteFileConcurrentModification(t *testing.T) {
+	directory := tempDir(t)
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "Pazuzufile")
+
+	ioutil.WriteFile(path, []byte("base: ubuntu\n"), 0644)
+	if _, err := ReadFile(path); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	ioutil.WriteFile(path, []byte("base: debian\n"), 0644)
+	if err := WriteFile(path, []byte("base: alpine\n")); err == nil {
+		t.Error("modified file should not be overwritten")
+	}
+	contents, _ := ioutil.ReadFile(path)
+	if string(contents) != "base: debian\n" {
+		t.Errorf("modified file should be kept: %s", contents)
+	}
+}
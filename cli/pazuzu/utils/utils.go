@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/zalando-incubator/pazuzu"
@@ -45,14 +45,12 @@ func appendIfMissing(slice []string, element string) []string {
 // Reads Pazuzufile
 // returns PazuzuFile struct and a success flag
 func ReadPazuzuFile(path string) (*pazuzu.PazuzuFile, bool) {
-	file, err := os.Open(path)
+	contents, err := ReadFile(path)
 	if err != nil {
 		return nil, false
 	}
-	defer file.Close()
 
-	reader := bufio.NewReader(file)
-	pazuzuFile, err := pazuzu.Read(reader)
+	pazuzuFile, err := pazuzu.Read(bytes.NewReader(contents))
 	if err != nil {
 		return nil, false
 	}
@@ -60,49 +58,49 @@ func ReadPazuzuFile(path string) (*pazuzu.PazuzuFile, bool) {
 	return &pazuzuFile, true
 }
 
-func WritePazuzuFile(path string, pazuzuFile *pazuzu.PazuzuFile) error {
-	// TODO: do it safer way (#108)
-	file, err := os.Create(path)
+// Reads the lockfile, an empty one is returned if it does not exist. Lockfiles edited
+// manually are refused, as the features recorded in them are trusted when generating.
+func ReadLockfile(path string) (pazuzu.Lockfile, error) {
+	contents, err := ReadFile(path)
+	if os.IsNotExist(err) {
+		return pazuzu.Lockfile{}, nil
+	}
 	if err != nil {
-		return errors.New(fmt.Sprintf("Could not create %v", pazuzu.PazuzufileName))
+		return pazuzu.Lockfile{}, err
+	}
+	// the recorded features are used as they are, so they have to be the generated ones
+	if generated, modified := pazuzu.CheckGenerated(contents); !generated || modified {
+		return pazuzu.Lockfile{}, fmt.Errorf("%v was not generated by pazuzu or was edited manually, "+
+			"remove it to resolve the features from the registry again", path)
 	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-	pazuzu.Write(writer, *pazuzuFile)
 
-	writer.Flush()
-	return nil
+	lockfile, err := pazuzu.ReadLockfile(bytes.NewReader(contents))
+	if err != nil {
+		return pazuzu.Lockfile{}, fmt.Errorf("Can not read %v: %v", path, err)
+	}
+	return lockfile, nil
 }
 
-func WriteFile(path string, contents []byte) error {
-	file, err := os.Create(path)
+func WritePazuzuFile(path string, pazuzuFile *pazuzu.PazuzuFile) error {
+	var buffer bytes.Buffer
+	err := pazuzu.Write(&buffer, *pazuzuFile)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Could not create %v", path))
+		return fmt.Errorf("Could not create %v: %v", pazuzu.PazuzufileName, err)
 	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-	writer.Write(contents)
-	writer.Flush()
 
-	return nil
+	return WriteFile(path, buffer.Bytes())
 }
 
+// CheckFeaturesInRepository fails if one of the features is neither in the storage nor
+// provided by one of its features, suggesting similar features.
 func CheckFeaturesInRepository(names []string, storage storageconnector.StorageReader) ([]string, error) {
-	var features []string
-
 	for _, name := range names {
 		log.Printf("Checking: %v\n", name)
-
-		meta, err := storage.GetMeta(name)
-		if err != nil {
-			return features, errors.New(fmt.Sprintf("Feature %v not found", name))
-		}
-		features = append(features, fmt.Sprintf("%v", meta.Name))
 	}
-
-	return features, nil
+	if err := pazuzu.CheckFeatures(storage, names); err != nil {
+		return nil, err
+	}
+	return names, nil
 }
 
 func CheckDestination(destination string) error {
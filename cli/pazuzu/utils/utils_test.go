@@ -2,7 +2,13 @@ package utils
 
 import (
 	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/mock"
+	"github.com/zalando-incubator/pazuzu/shared"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -99,3 +105,67 @@ func TestGenerateFeaturesList(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckFeaturesInRepository(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node"}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "java"}},
+	)
+
+	t.Run("Returns found features", func(t *testing.T) {
+		result, err := CheckFeaturesInRepository([]string{"java", "node"}, storage)
+		if !reflect.DeepEqual(result, []string{"java", "node"}) || err != nil {
+			t.Errorf("Result differs from expected: %s", result)
+		}
+	})
+
+	t.Run("Suggests similar features", func(t *testing.T) {
+		_, err := CheckFeaturesInRepository([]string{"nodejs"}, storage)
+		if err == nil || err.Error() != "Feature nodejs not found, did you mean: node?" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestReadLockfile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pazuzu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	locked := []byte("features:\n- name: python\n  hash: abc\n")
+	tests := []struct {
+		name         string
+		contents     []byte
+		wantFeatures int
+		wantErr      bool
+	}{
+		{"Generated", pazuzu.AddGeneratedHeader(locked), 1, false},
+		{"Edited", append(pazuzu.AddGeneratedHeader(locked), "- name: node\n  hash: def\n"...), 0, true},
+		{"Without header", locked, 0, true},
+		{"Missing", nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(directory, strings.Replace(tt.name, " ", "-", -1))
+			if tt.contents != nil {
+				ioutil.WriteFile(path, tt.contents, 0644)
+			}
+
+			lockfile, err := ReadLockfile(path)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "edited manually") {
+					t.Errorf("ReadLockfile() error = %v, edited lockfile should be refused", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadLockfile() should not fail: %s", err)
+			}
+			if len(lockfile.Features) != tt.wantFeatures {
+				t.Errorf("ReadLockfile() = %v, want %d features", lockfile, tt.wantFeatures)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/zalando-incubator/pazuzu"
+	"github.com/zalando-incubator/pazuzu/shared"
 	"github.com/zalando-incubator/pazuzu/storageconnector"
 )
 
@@ -45,11 +46,25 @@ type RegistryConfig struct {
 	Scheme   string `yaml:"scheme" setter:"SetScheme" help:"Scheme String"`
 }
 
+// DockerConfig : config structure for connecting to the Docker daemon. The DOCKER_HOST,
+// DOCKER_TLS_VERIFY, DOCKER_CERT_PATH and DOCKER_API_VERSION environment variables take precedence.
+type DockerConfig struct {
+	Endpoint   string `yaml:"endpoint" setter:"SetEndpoint" help:"Docker daemon endpoint (ex: 'tcp://docker.example.org:2376'), the local socket by default"`
+	TLSVerify  bool   `yaml:"tls_verify" setter:"SetTLSVerify" help:"Verify the certificate of the Docker daemon with ca.pem in cert_path (true/false)"`
+	CertPath   string `yaml:"cert_path" setter:"SetCertPath" help:"Directory with cert.pem, key.pem and ca.pem for connecting over TLS"`
+	APIVersion string `yaml:"api_version" setter:"SetAPIVersion" help:"Docker API version (ex: '1.24'), the version of the daemon by default"`
+}
+
 // Config : actual config data structure.
 type Config struct {
 	Base        string         `yaml:"base" setter:"SetBase" help:"Base image name and tag (ex: 'ubuntu:14.04')"`
 	StorageType string         `yaml:"storage" setter:"SetStorageType" help:"Storage-type(registry) "`
+	Providers   string         `yaml:"providers" setter:"SetProviders" help:"Default providers of virtual features (ex: 'jdk=openjdk-8,python=python3')"`
+	Bats        string         `yaml:"bats" setter:"SetBats" help:"Local bats distribution, directory or .tar(.gz) archive (bats is expected in the image if empty)"`
+	Backup      bool           `yaml:"backup" setter:"SetBackup" help:"Keep the previous version of overwritten project files as .bak (true/false)"`
+	TestTimeout string         `yaml:"test_timeout" setter:"SetTestTimeout" help:"Maximum run time of the tests of a feature (ex: '10m'), no limit if empty"`
 	Registry    RegistryConfig `yaml:"registry" help:"Pazuzu-registry configs"`
+	Docker      DockerConfig   `yaml:"docker" help:"Docker daemon configs"`
 }
 
 // SetBase : Setter of "Base".
@@ -62,6 +77,43 @@ func (c *Config) SetStorageType(storageType string) {
 	c.StorageType = storageType
 }
 
+// SetProviders : Setter of "Providers".
+func (c *Config) SetProviders(providers string) {
+	c.Providers = providers
+}
+
+// SetBats : Setter of "Bats".
+func (c *Config) SetBats(bats string) {
+	c.Bats = bats
+}
+
+// SetBackup : Setter of "Backup".
+func (c *Config) SetBackup(backup bool) {
+	c.Backup = backup
+}
+
+// SetTestTimeout : Setter of "TestTimeout".
+func (c *Config) SetTestTimeout(timeout string) {
+	c.TestTimeout = timeout
+}
+
+// DefaultProviders : parse "Providers" into a map of virtual feature to its default provider.
+func (c *Config) DefaultProviders() map[string]string {
+	providers := map[string]string{}
+	for _, pair := range strings.Split(c.Providers, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		virtual := strings.TrimSpace(parts[0])
+		provider := strings.TrimSpace(parts[1])
+		if virtual != "" && provider != "" {
+			providers[virtual] = provider
+		}
+	}
+	return providers
+}
+
 // SetRegistryHostname : Setter of RegistryConfig.Hostname.
 func (r *RegistryConfig) SetHostname(hostname string) {
 	r.Hostname = hostname
@@ -77,6 +129,36 @@ func (r *RegistryConfig) SetScheme(scheme string) {
 	r.Scheme = scheme
 }
 
+// SetEndpoint : Setter of DockerConfig.Endpoint.
+func (d *DockerConfig) SetEndpoint(endpoint string) {
+	d.Endpoint = endpoint
+}
+
+// SetTLSVerify : Setter of DockerConfig.TLSVerify.
+func (d *DockerConfig) SetTLSVerify(verify bool) {
+	d.TLSVerify = verify
+}
+
+// SetCertPath : Setter of DockerConfig.CertPath.
+func (d *DockerConfig) SetCertPath(certPath string) {
+	d.CertPath = certPath
+}
+
+// SetAPIVersion : Setter of DockerConfig.APIVersion.
+func (d *DockerConfig) SetAPIVersion(version string) {
+	d.APIVersion = version
+}
+
+// DockerOptions : options for connecting to the Docker daemon, overridden by the environment.
+func (c *Config) DockerOptions() pazuzu.DockerOptions {
+	return pazuzu.DockerOptions{
+		Endpoint:   c.Docker.Endpoint,
+		TLSVerify:  c.Docker.TLSVerify,
+		CertPath:   c.Docker.CertPath,
+		APIVersion: c.Docker.APIVersion,
+	}.WithEnvironment()
+}
+
 // InitDefaultConfig : Initialize config variable with defaults. (Does not loading configuration file)
 func InitDefaultConfig() {
 	config = Config{
@@ -102,9 +184,19 @@ func GetConfig() *Config {
 
 // GetStorageReader : create new StorageReader by StorageType of given config.
 func GetStorageReader(config Config) (storageconnector.StorageReader, error) {
+	return GetPinnedStorageReader(config, nil)
+}
+
+// GetPinnedStorageReader : create new StorageReader by StorageType of given config,
+// which uses the pinned versions of features instead of the stored ones.
+func GetPinnedStorageReader(config Config, pinned map[string]shared.Feature) (storageconnector.StorageReader, error) {
 	switch config.StorageType {
 	case StorageTypeRegistry:
-		return storageconnector.NewRegistryStorage(config.Registry.Hostname, config.Registry.Port, config.Registry.Scheme, nil)
+		registry, err := storageconnector.NewRegistryStorage(config.Registry.Hostname, config.Registry.Port, config.Registry.Scheme, nil)
+		if err != nil {
+			return nil, err
+		}
+		return storageconnector.NewPinnedStorage(registry, config.DefaultProviders(), pinned), nil
 	}
 
 	return nil, fmt.Errorf("unknown storage type '%s'", config.StorageType)
@@ -340,6 +432,10 @@ func valToReflectValue(setter reflect.Value, val string) (reflect.Value, error)
 		integerArg, err := strconv.Atoi(val)
 		return reflect.ValueOf(integerArg), err
 
+	case reflect.Bool:
+		boolArg, err := strconv.ParseBool(val)
+		return reflect.ValueOf(boolArg), err
+
 	default:
 		return reflect.ValueOf(val), nil
 	}
@@ -143,3 +143,27 @@ func TestValueToReflectValue(t *testing.T) {
 		t.Error("Couldn't parse integer correctly.")
 	}
 }
+
+func dummySetterWithBool(value bool) {}
+
+func TestValueToReflectValueBool(t *testing.T) {
+	setter := reflect.ValueOf(dummySetterWithBool)
+	val, err := valToReflectValue(setter, "true")
+	if err != nil || !val.Bool() {
+		t.Error("Couldn't parse boolean correctly.")
+	}
+	if _, err := valToReflectValue(setter, "maybe"); err == nil {
+		t.Error("Invalid boolean should not be parsed.")
+	}
+}
+
+func TestConfigDefaultProviders(t *testing.T) {
+	config := getConfig(t)
+
+	config.SetProviders("jdk=openjdk-8, python = python3,broken,=empty")
+
+	expected := map[string]string{"jdk": "openjdk-8", "python": "python3"}
+	if !reflect.DeepEqual(config.DefaultProviders(), expected) {
+		t.Errorf("DefaultProviders FAIL! [%v]", config.DefaultProviders())
+	}
+}
@@ -0,0 +1,99 @@
+package pazuzu
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// testContainer is a running test container. It is killed when tests time out or
+// pazuzu is interrupted, which ends the running tests.
+type testContainer struct {
+	client DockerClient
+	ID     string
+
+	mutex  sync.Mutex
+	reason string // why the container was killed, empty while it is running
+}
+
+// kill kills the container, only the first reason is kept.
+func (c *testContainer) kill(reason string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.reason != "" {
+		return
+	}
+	c.reason = reason
+	c.client.KillContainer(docker.KillContainerOptions{ID: c.ID})
+}
+
+// killed returns why the container was killed, empty if it was not.
+func (c *testContainer) killed() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.reason
+}
+
+// killAfter kills the container if the returned timer is not stopped within the timeout.
+// No timer is started for a timeout of 0.
+func (c *testContainer) killAfter(timeout time.Duration, reason string) *time.Timer {
+	if timeout <= 0 {
+		return nil
+	}
+	return time.AfterFunc(timeout, func() {
+		c.kill(fmt.Sprintf("%s timed out after %s", reason, timeout))
+	})
+}
+
+// killOnCancel kills the container when the context is cancelled, i.e. pazuzu is interrupted,
+// so that the tests return and the container is removed as usual. The returned function stops watching.
+func (c *testContainer) killOnCancel(ctx context.Context) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			c.kill(interrupted)
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// watchInterrupt returns a context which is cancelled when pazuzu is interrupted (e.g. by Ctrl-C),
+// so that the running build or tests are ended and cleaned up. After the first signal the default
+// handling is restored, so that a second one terminates pazuzu if the cleanup hangs.
+// The returned function stops watching.
+func watchInterrupt() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// interrupted is the reason of killing the test container when pazuzu is interrupted.
+const interrupted = "interrupted"
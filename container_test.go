@@ -0,0 +1,86 @@
+package pazuzu
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zalando-incubator/pazuzu/mock"
+)
+
+func waitKilled(container *testContainer) {
+	deadline := time.Now().Add(time.Second)
+	for container.killed() == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTestContainerKill(t *testing.T) {
+	client := mock.NewDockerClient()
+	container := &testContainer{client: client, ID: "test"}
+
+	if timer := container.killAfter(0, "Tests"); timer != nil {
+		t.Error("no timer should be started without a timeout")
+	}
+	if timer := container.killAfter(time.Hour, "Tests"); timer == nil {
+		t.Error("a timer should be started")
+	} else {
+		timer.Stop()
+	}
+	if reason := container.killed(); reason != "" {
+		t.Errorf("container should not be killed, got %q", reason)
+	}
+
+	container.killAfter(time.Millisecond, "Tests in test.bats")
+	waitKilled(container)
+	container.kill(interrupted)
+
+	if reason, want := container.killed(), "Tests in test.bats timed out after 1ms"; reason != want {
+		t.Errorf("killed() = %q, want %q", reason, want)
+	}
+	if want := []string{"KillContainer test"}; !reflect.DeepEqual(client.Calls, want) {
+		t.Errorf("calls = %v, want %v", client.Calls, want)
+	}
+}
+
+func TestTestContainerKillOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	container := &testContainer{client: mock.NewDockerClient(), ID: "test"}
+	stop := container.killOnCancel(ctx)
+	defer stop()
+
+	cancel()
+	waitKilled(container)
+	if reason := container.killed(); reason != interrupted {
+		t.Errorf("killed() = %q, want %q", reason, interrupted)
+	}
+
+	container = &testContainer{client: mock.NewDockerClient(), ID: "test"}
+	ctx, cancel = context.WithCancel(context.Background())
+	container.killOnCancel(ctx)()
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	if reason := container.killed(); reason != "" {
+		t.Errorf("container should not be killed after watching stopped, got %q", reason)
+	}
+}
+
+func TestWatchInterrupt(t *testing.T) {
+	ctx, stop := watchInterrupt()
+	defer stop()
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("interrupt can not be sent: %s", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("context should be cancelled on interrupt")
+	}
+}
@@ -0,0 +1,77 @@
+package pazuzu
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// DockerClient is the part of the Docker API used to build and test images. It is
+// implemented by *docker.Client, see NewDockerClient, and by mock.DockerClient for tests.
+type DockerClient interface {
+	BuildImage(opts docker.BuildImageOptions) error
+	InspectImage(name string) (*docker.Image, error)
+	RemoveImage(name string) error
+
+	CreateContainer(opts docker.CreateContainerOptions) (*docker.Container, error)
+	StartContainer(id string, hostConfig *docker.HostConfig) error
+	StopContainer(id string, timeout uint) error
+	KillContainer(opts docker.KillContainerOptions) error
+	RemoveContainer(opts docker.RemoveContainerOptions) error
+	UploadToContainer(id string, opts docker.UploadToContainerOptions) error
+
+	CreateExec(opts docker.CreateExecOptions) (*docker.Exec, error)
+	StartExec(id string, opts docker.StartExecOptions) error
+	InspectExec(id string) (*docker.ExecInspect, error)
+}
+
+// DockerOptions configure the connection to the Docker daemon.
+type DockerOptions struct {
+	Endpoint   string // DefaultDockerEndpoint if empty
+	TLSVerify  bool   // verify the certificate of the daemon with ca.pem in CertPath
+	CertPath   string // directory with cert.pem, key.pem and ca.pem, TLS is used if set
+	APIVersion string // default version of the daemon if empty
+}
+
+// WithEnvironment returns the options overridden by the environment variables used by
+// the docker command: DOCKER_HOST, DOCKER_TLS_VERIFY, DOCKER_CERT_PATH and DOCKER_API_VERSION.
+func (o DockerOptions) WithEnvironment() DockerOptions {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		o.Endpoint = host
+	}
+	if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+		o.TLSVerify = true
+	}
+	if certPath := os.Getenv("DOCKER_CERT_PATH"); certPath != "" {
+		o.CertPath = certPath
+	}
+	if version := os.Getenv("DOCKER_API_VERSION"); version != "" {
+		o.APIVersion = version
+	}
+	return o
+}
+
+// NewDockerClient connects to the Docker daemon given by the options.
+func NewDockerClient(options DockerOptions) (*docker.Client, error) {
+	endpoint := options.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultDockerEndpoint
+	}
+
+	certPath := options.CertPath
+	if certPath == "" && options.TLSVerify {
+		certPath = filepath.Join(os.Getenv("HOME"), ".docker")
+	}
+	if certPath == "" {
+		return docker.NewVersionedClient(endpoint, options.APIVersion)
+	}
+
+	// without a CA certificate, the certificate of the daemon is not verified
+	ca := ""
+	if options.TLSVerify {
+		ca = filepath.Join(certPath, "ca.pem")
+	}
+	return docker.NewVersionedTLSClient(endpoint,
+		filepath.Join(certPath, "cert.pem"), filepath.Join(certPath, "key.pem"), ca, options.APIVersion)
+}
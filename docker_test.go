@@ -0,0 +1,292 @@
+package pazuzu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu/mock"
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+func setEnv(t *testing.T, env map[string]string) func() {
+	previous := map[string]string{}
+	for key, value := range env {
+		previous[key] = os.Getenv(key)
+		if err := os.Setenv(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return func() {
+		for key, value := range previous {
+			os.Setenv(key, value)
+		}
+	}
+}
+
+func TestDockerOptionsWithEnvironment(t *testing.T) {
+	configured := DockerOptions{Endpoint: "tcp://configured:2376", CertPath: "/configured", APIVersion: "1.24"}
+
+	tests := []struct {
+		env  map[string]string
+		want DockerOptions
+	}{
+		{map[string]string{}, configured},
+		{map[string]string{
+			"DOCKER_HOST":        "tcp://docker.example.org:2376",
+			"DOCKER_TLS_VERIFY":  "1",
+			"DOCKER_CERT_PATH":   "/certs",
+			"DOCKER_API_VERSION": "1.25",
+		}, DockerOptions{Endpoint: "tcp://docker.example.org:2376", TLSVerify: true, CertPath: "/certs", APIVersion: "1.25"}},
+	}
+	for _, tt := range tests {
+		env := map[string]string{"DOCKER_HOST": "", "DOCKER_TLS_VERIFY": "", "DOCKER_CERT_PATH": "", "DOCKER_API_VERSION": ""}
+		for key, value := range tt.env {
+			env[key] = value
+		}
+		restore := setEnv(t, env)
+		got := configured.WithEnvironment()
+		restore()
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WithEnvironment() with %v = %+v, want %+v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestNewDockerClient(t *testing.T) {
+	client, err := NewDockerClient(DockerOptions{})
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if client.TLSConfig != nil {
+		t.Error("TLS should not be used without certificates")
+	}
+
+	client, err = NewDockerClient(DockerOptions{Endpoint: "tcp://localhost:2376", CertPath: os.TempDir()})
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if client.TLSConfig == nil || !client.TLSConfig.InsecureSkipVerify {
+		t.Errorf("TLS without verification should be used: %+v", client.TLSConfig)
+	}
+}
+
+func generatedPazuzu(t *testing.T, client *mock.DockerClient, features ...shared.Feature) *Pazuzu {
+	var names []string
+	for _, feature := range features {
+		names = append(names, feature.Meta.Name)
+	}
+	pazuzu := &Pazuzu{StorageReader: mock.NewFeatureStorage(features...), DockerClient: client, Output: ioutil.Discard}
+	if err := pazuzu.Generate("ubuntu", names); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	return pazuzu
+}
+
+var pythonFeature = shared.Feature{
+	Meta:        shared.FeatureMeta{Name: "python"},
+	Snippet:     "RUN apt-get install python --yes",
+	TestSnippet: "@test \"python is installed\" {\n\tpython -V\n}",
+}
+
+func TestDockerBuildWithClient(t *testing.T) {
+	client := mock.NewDockerClient()
+	command := "bats -t /pazuzu/tests/python/test.bats"
+	client.Stdout[command] = "1..1\nok 1 python is installed\n"
+
+	pazuzu := generatedPazuzu(t, client, pythonFeature)
+	if err := pazuzu.DockerBuild("hello"); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := []string{
+		"BuildImage hello",
+		"InspectImage hello",
+		"CreateContainer hello",
+		"StartContainer container-1",
+		"UploadToContainer container-1",
+		"CreateExec " + batsCheck,
+		"StartExec " + batsCheck,
+		"CreateExec " + command,
+		"StartExec " + command,
+		"StopContainer container-1",
+		"RemoveContainer container-1",
+	}
+	if !reflect.DeepEqual(client.Calls, want) {
+		t.Errorf("calls = %v, want %v", client.Calls, want)
+	}
+	if !strings.Contains(string(client.Images["hello"]), pythonFeature.Snippet) {
+		t.Errorf("image should be built from the Dockerfile: %s", client.Images["hello"])
+	}
+	if _, ok := client.Files["/pazuzu/tests/python/test.bats"]; !ok {
+		t.Errorf("tests should be uploaded: %v", client.Files)
+	}
+	if len(pazuzu.TestResults) != 1 || pazuzu.TestResults[0].Tests[0].Status != TestPassed {
+		t.Errorf("test results = %v", pazuzu.TestResults)
+	}
+}
+
+// buildOutput returns the steps docker build shows for the Dockerfile up to the failing instruction.
+func buildOutput(dockerfile []byte, failing string) string {
+	var instructions []string
+	for _, line := range strings.Split(string(dockerfile), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			instructions = append(instructions, line)
+		}
+	}
+
+	var output bytes.Buffer
+	for i, instruction := range instructions {
+		fmt.Fprintf(&output, "Step %d/%d : %s\n", i+1, len(instructions), instruction)
+		if strings.HasPrefix(instruction, failing) {
+			break
+		}
+	}
+	return output.String()
+}
+
+func TestDockerBuildFailure(t *testing.T) {
+	tests := []struct {
+		failing string
+		want    string
+	}{
+		{pythonFeature.Snippet, "python failed at step 2 (" + pythonFeature.Snippet + ")"},
+		{"CMD", "default command failed at step 3 (CMD /bin/bash)"},
+	}
+	for _, tt := range tests {
+		client := mock.NewDockerClient()
+		client.BuildErrors["hello"] = errors.New("The command returned a non-zero code: 100")
+
+		pazuzu := generatedPazuzu(t, client, pythonFeature)
+		client.BuildOutput["hello"] = buildOutput(pazuzu.Dockerfile, tt.failing)
+		if err := pazuzu.DockerBuild("hello"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("failing build should name the failed section %q: %v", tt.want, err)
+		}
+		if len(client.Calls) != 1 {
+			t.Errorf("nothing should be run after the build failed: %v", client.Calls)
+		}
+	}
+}
+
+func TestDockerTestFailure(t *testing.T) {
+	command := "bats -t /pazuzu/tests/python/test.bats"
+
+	for _, keep := range []bool{false, true} {
+		client := mock.NewDockerClient("hello")
+		client.Stdout[command] = "1..1\nnot ok 1 python is installed\n"
+		client.ExitCodes[command] = 1
+
+		pazuzu := generatedPazuzu(t, client, pythonFeature)
+		pazuzu.KeepContainer = keep
+		if err := pazuzu.DockerTest("hello"); err == nil {
+			t.Error("failing tests should fail")
+		}
+		if len(pazuzu.TestResults) != 1 || pazuzu.TestResults[0].Tests[0].Status != TestFailed {
+			t.Errorf("test results = %v", pazuzu.TestResults)
+		}
+		if kept := len(client.Containers) == 1; kept != keep {
+			t.Errorf("with KeepContainer %v, containers = %v", keep, client.Containers)
+		}
+	}
+}
+
+func TestDockerTestDependencies(t *testing.T) {
+	client := mock.NewDockerClient("hello")
+	python := pythonFeature
+	python.Meta.TestDependencies = []string{"pytest"}
+	pytest := shared.Feature{Meta: shared.FeatureMeta{Name: "pytest"}, Snippet: "RUN pip install pytest"}
+
+	pazuzu := &Pazuzu{StorageReader: mock.NewFeatureStorage(python, pytest), DockerClient: client, Output: ioutil.Discard}
+	if err := pazuzu.Generate("ubuntu", []string{"python"}); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if err := pazuzu.DockerTest("hello"); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	var testImage string
+	for _, call := range client.Calls {
+		if strings.HasPrefix(call, "BuildImage ") {
+			testImage = strings.TrimPrefix(call, "BuildImage ")
+		}
+	}
+	if !strings.HasPrefix(testImage, "hello:test-") {
+		t.Fatalf("test image should be built: %v", client.Calls)
+	}
+	if _, ok := client.Images[testImage]; ok {
+		t.Errorf("test image should be removed: %v", client.Images)
+	}
+	if len(client.Containers) != 0 {
+		t.Errorf("containers should be removed: %v", client.Containers)
+	}
+}
+
+func TestDockerTestDependenciesBuildFailure(t *testing.T) {
+	python := pythonFeature
+	python.Meta.TestDependencies = []string{"pytest"}
+	pytest := shared.Feature{Meta: shared.FeatureMeta{Name: "pytest"}, Snippet: "RUN pip install pytest"}
+
+	interrupt, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		buildFail bool
+		want      string
+	}{
+		{"Build failure", context.Background(), true, "pip failed"},
+		{"Interrupted build", interrupt, false, "Build interrupted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mock.NewDockerClient("hello")
+			pazuzu := &Pazuzu{StorageReader: mock.NewFeatureStorage(python, pytest), DockerClient: client, Output: ioutil.Discard}
+			if err := pazuzu.Generate("ubuntu", []string{"python"}); err != nil {
+				t.Fatalf("should not fail: %s", err)
+			}
+			if tt.buildFail {
+				client.InstructionErrors[pytest.Snippet] = errors.New("pip failed")
+			}
+
+			err := pazuzu.dockerTest(tt.ctx, "hello")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("dockerTest() = %v, want %q", err, tt.want)
+			}
+			if call := client.Calls[len(client.Calls)-1]; !strings.HasPrefix(call, "RemoveImage hello:test-") {
+				t.Errorf("test image should be removed: %v", client.Calls)
+			}
+		})
+	}
+}
+
+func TestDockerTestMissingBats(t *testing.T) {
+	client := mock.NewDockerClient("hello")
+	client.ExitCodes[batsCheck] = 127
+
+	pazuzu := generatedPazuzu(t, client, pythonFeature)
+	if err := pazuzu.DockerTest("hello"); err == nil || !strings.Contains(err.Error(), "pazuzu config set bats") {
+		t.Errorf("missing bats should point to the bats setting: %v", err)
+	}
+	for _, call := range client.Calls {
+		if strings.Contains(call, "test.bats") {
+			t.Errorf("tests should not be run without bats: %v", client.Calls)
+		}
+	}
+	if len(client.Containers) != 0 {
+		t.Errorf("containers should be removed: %v", client.Containers)
+	}
+}
+
+func TestDockerTestMissingImage(t *testing.T) {
+	pazuzu := generatedPazuzu(t, mock.NewDockerClient(), pythonFeature)
+	if err := pazuzu.DockerTest("hello"); err == nil {
+		t.Error("testing a missing image should fail")
+	}
+}
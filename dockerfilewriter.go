@@ -13,7 +13,8 @@ import (
 var ErrInvalidCopyCmdSyntax = fmt.Errorf("Invalid 'COPY' command syntax")
 
 type DockerfileWriter struct {
-	buf *bytes.Buffer
+	buf     *bytes.Buffer
+	sources []string
 }
 
 func NewDockerfileWriter() *DockerfileWriter {
@@ -61,6 +62,7 @@ func (c *DockerfileWriter) AppendFeature(feature shared.Feature) error {
 				return err
 			}
 			c.AppendRaw(fixedCmd)
+			c.sources = append(c.sources, fmt.Sprintf("%s/%s", feature.Meta.Name, cmdNode.Next.Value))
 		} else {
 			c.AppendRaw(cmdNode.Original)
 		}
@@ -72,3 +74,8 @@ func (c *DockerfileWriter) AppendFeature(feature shared.Feature) error {
 func (c *DockerfileWriter) Bytes() []byte {
 	return c.buf.Bytes()
 }
+
+// CopySources returns the paths copied by the features into the image, relative to the build context.
+func (c *DockerfileWriter) CopySources() []string {
+	return c.sources
+}
@@ -2,6 +2,7 @@ package pazuzu
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -58,4 +59,9 @@ func TestDockerfileWriter(t *testing.T) {
 			}
 		}
 	}
+
+	sources := []string{"feature-1/a/b/c", "feature-1/c", "feature-2/lib.jar"}
+	if got := writer.CopySources(); !reflect.DeepEqual(got, sources) {
+		t.Errorf("CopySources() = %v, want %v", got, sources)
+	}
 }
@@ -10,4 +10,5 @@ var (
 	ErrNotFound               = errors.New("Not found")
 	ErrInitAndAddAreSpecified = errors.New("Conflict: both `add` and `init` parameters are specified")
 	ErrInvalidConfigValue     = errors.New("Can not parse value to required type")
+	ErrUnknownSetting         = errors.New("Key is not supported")
 )
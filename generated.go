@@ -0,0 +1,69 @@
+package pazuzu
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Marks files generated by pazuzu, followed by the checksum of the rest of the file.
+const generatedHeader = "# Generated by pazuzu, do not edit. Checksum: "
+
+// AddGeneratedHeader marks the contents as generated by pazuzu. The header records the checksum
+// of the contents, so that manual edits can be detected later. It is placed after a shebang line.
+func AddGeneratedHeader(contents []byte) []byte {
+	shebang, body := splitShebang(contents)
+
+	var buffer bytes.Buffer
+	buffer.Write(shebang)
+	buffer.WriteString(generatedHeader + checksum(shebang, body) + "\n")
+	buffer.Write(body)
+	return buffer.Bytes()
+}
+
+// CheckGenerated reports whether the contents were generated by pazuzu and whether they were
+// modified since.
+func CheckGenerated(contents []byte) (generated bool, modified bool) {
+	shebang, rest := splitShebang(contents)
+	if !bytes.HasPrefix(rest, []byte(generatedHeader)) {
+		return false, false
+	}
+
+	header, body := rest, []byte{}
+	if end := bytes.IndexByte(rest, '\n'); end >= 0 {
+		header, body = rest[:end], rest[end+1:]
+	}
+	return true, string(header[len(generatedHeader):]) != checksum(shebang, body)
+}
+
+// StripGeneratedHeader returns the contents without the header added by AddGeneratedHeader.
+func StripGeneratedHeader(contents []byte) []byte {
+	shebang, rest := splitShebang(contents)
+	if !bytes.HasPrefix(rest, []byte(generatedHeader)) {
+		return contents
+	}
+
+	var body []byte
+	if end := bytes.IndexByte(rest, '\n'); end >= 0 {
+		body = rest[end+1:]
+	}
+	return append(append([]byte{}, shebang...), body...)
+}
+
+func splitShebang(contents []byte) ([]byte, []byte) {
+	if !bytes.HasPrefix(contents, []byte("#!")) {
+		return nil, contents
+	}
+	end := bytes.IndexByte(contents, '\n')
+	if end < 0 {
+		return contents, nil
+	}
+	return contents[:end+1], contents[end+1:]
+}
+
+func checksum(shebang []byte, body []byte) string {
+	hash := sha256.New()
+	hash.Write(shebang)
+	hash.Write(body)
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,40 @@
+package pazuzu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratedHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		prefix   string
+	}{
+		{"Dockerfile", "FROM ubuntu\nCMD /bin/bash\n", generatedHeader},
+		{"Script", "#!/usr/bin/env bats\n\n@test \"true\" {\n}\n", "#!/usr/bin/env bats\n" + generatedHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := AddGeneratedHeader([]byte(tt.contents))
+			if !strings.HasPrefix(string(contents), tt.prefix) {
+				t.Errorf("wrong header:\n%s", contents)
+			}
+			if generated, modified := CheckGenerated(contents); !generated || modified {
+				t.Errorf("CheckGenerated() = %v, %v, want unmodified generated file", generated, modified)
+			}
+			if stripped := StripGeneratedHeader(contents); string(stripped) != tt.contents {
+				t.Errorf("StripGeneratedHeader() = %q, want %q", stripped, tt.contents)
+			}
+
+			edited := append(contents, []byte("RUN echo edited\n")...)
+			if generated, modified := CheckGenerated(edited); !generated || !modified {
+				t.Errorf("CheckGenerated() = %v, %v, want modified generated file", generated, modified)
+			}
+		})
+	}
+
+	if generated, _ := CheckGenerated([]byte("FROM ubuntu\n")); generated {
+		t.Error("file without header should not be reported as generated")
+	}
+}
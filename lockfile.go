@@ -0,0 +1,158 @@
+package pazuzu
+
+import (
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+// Kinds of FeatureChange.
+const (
+	FeatureAdded   = "added"
+	FeatureRemoved = "removed"
+	FeatureUpdated = "updated"
+)
+
+// Lockfile records the features resolved when the project files were generated.
+// The recorded features are used by later generations instead of the registry versions,
+// so that changes in the registry are picked up only deliberately.
+type Lockfile struct {
+	Features []LockedFeature `json:"features" yaml:"features"`
+}
+
+// LockedFeature identifies the version of a resolved feature and keeps its content.
+type LockedFeature struct {
+	Name      string `json:"name" yaml:"name"`
+	UpdatedAt string `json:"updated_at" yaml:"updated_at"`
+	Hash      string `json:"hash" yaml:"hash"`
+
+	Dependencies         []string `json:"-" yaml:"dependencies,omitempty"`
+	OptionalDependencies []string `json:"-" yaml:"optional_dependencies,omitempty"`
+	TestDependencies     []string `json:"-" yaml:"test_dependencies,omitempty"`
+	TestRunner           string   `json:"-" yaml:"test_runner,omitempty"`
+	Conflicts            []string `json:"-" yaml:"conflicts,omitempty"`
+	Provides             []string `json:"-" yaml:"provides,omitempty"`
+	Snippet              string   `json:"-" yaml:"snippet,omitempty"`
+	TestSnippet          string   `json:"-" yaml:"test_snippet,omitempty"`
+}
+
+// Feature restores the locked feature.
+func (l LockedFeature) Feature() shared.Feature {
+	updatedAt, _ := time.Parse(time.RFC3339, l.UpdatedAt)
+	return shared.Feature{
+		Meta: shared.FeatureMeta{
+			Name:                 l.Name,
+			UpdatedAt:            updatedAt,
+			Dependencies:         l.Dependencies,
+			OptionalDependencies: l.OptionalDependencies,
+			TestDependencies:     l.TestDependencies,
+			TestRunner:           l.TestRunner,
+			Conflicts:            l.Conflicts,
+			Provides:             l.Provides,
+		},
+		Snippet:     l.Snippet,
+		TestSnippet: l.TestSnippet,
+	}
+}
+
+// FeatureChange describes how a feature changed between two resolutions.
+// Old is not set for added features, New is not set for removed ones.
+type FeatureChange struct {
+	Name   string         `json:"name" yaml:"name"`
+	Change string         `json:"change" yaml:"change"`
+	Old    *LockedFeature `json:"old,omitempty" yaml:"old,omitempty"`
+	New    *LockedFeature `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// NewLockfile records the given resolved features in installation order.
+func NewLockfile(features []shared.Feature) Lockfile {
+	lockfile := Lockfile{Features: []LockedFeature{}}
+	for _, feature := range features {
+		locked := LockedFeature{
+			Name:                 feature.Meta.Name,
+			Hash:                 feature.Hash(),
+			Dependencies:         feature.Meta.Dependencies,
+			OptionalDependencies: feature.Meta.OptionalDependencies,
+			TestDependencies:     feature.Meta.TestDependencies,
+			TestRunner:           feature.Meta.TestRunner,
+			Conflicts:            feature.Meta.Conflicts,
+			Provides:             feature.Meta.Provides,
+			Snippet:              feature.Snippet,
+			TestSnippet:          feature.TestSnippet,
+		}
+		if !feature.Meta.UpdatedAt.IsZero() {
+			locked.UpdatedAt = feature.Meta.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		lockfile.Features = append(lockfile.Features, locked)
+	}
+	return lockfile
+}
+
+// Lookup returns the locked version of the feature.
+func (l Lockfile) Lookup(name string) (LockedFeature, bool) {
+	for _, feature := range l.Features {
+		if feature.Name == name {
+			return feature, true
+		}
+	}
+	return LockedFeature{}, false
+}
+
+// Pinned returns the locked features by name. Features whose content does not match
+// the recorded hash are left out, so they are taken from the registry again.
+func (l Lockfile) Pinned() map[string]shared.Feature {
+	pinned := map[string]shared.Feature{}
+	for _, locked := range l.Features {
+		if feature := locked.Feature(); feature.Hash() == locked.Hash {
+			pinned[locked.Name] = feature
+		}
+	}
+	return pinned
+}
+
+func ReadLockfile(reader io.Reader) (Lockfile, error) {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return Lockfile{}, err
+	}
+
+	lockfile := Lockfile{}
+	err = yaml.Unmarshal(content, &lockfile)
+	return lockfile, err
+}
+
+func WriteLockfile(writer io.Writer, lockfile Lockfile) error {
+	data, err := yaml.Marshal(lockfile)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(data)
+	return err
+}
+
+// DiffLockfiles lists the features added, updated or removed in the current lockfile
+// compared to the old one. Unchanged features are not listed.
+func DiffLockfiles(old Lockfile, current Lockfile) []FeatureChange {
+	changes := []FeatureChange{}
+	for i := range current.Features {
+		feature := &current.Features[i]
+		previous, ok := old.Lookup(feature.Name)
+		switch {
+		case !ok:
+			changes = append(changes, FeatureChange{Name: feature.Name, Change: FeatureAdded, New: feature})
+		case previous.Hash != feature.Hash || previous.UpdatedAt != feature.UpdatedAt:
+			changes = append(changes, FeatureChange{Name: feature.Name, Change: FeatureUpdated, Old: &previous, New: feature})
+		}
+	}
+	for i := range old.Features {
+		feature := &old.Features[i]
+		if _, ok := current.Lookup(feature.Name); !ok {
+			changes = append(changes, FeatureChange{Name: feature.Name, Change: FeatureRemoved, Old: feature})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,70 @@
+package pazuzu
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+func TestLockfileReadWrite(t *testing.T) {
+	lockfile := NewLockfile([]shared.Feature{
+		{Meta: shared.FeatureMeta{Name: "java", UpdatedAt: time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)}, Snippet: "RUN java"},
+		{Meta: shared.FeatureMeta{Name: "node"}, Snippet: "RUN node"},
+	})
+
+	var buffer bytes.Buffer
+	if err := WriteLockfile(&buffer, lockfile); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	read, err := ReadLockfile(&buffer)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if !reflect.DeepEqual(read, lockfile) {
+		t.Errorf("ReadLockfile() = %v, want %v", read, lockfile)
+	}
+	if read.Features[0].UpdatedAt != "2017-03-01T10:00:00Z" {
+		t.Errorf("wrong updated at: %s", read.Features[0].UpdatedAt)
+	}
+}
+
+func TestLockfilePinned(t *testing.T) {
+	lockfile := NewLockfile([]shared.Feature{
+		{Meta: shared.FeatureMeta{Name: "java", Dependencies: []string{"curl"}}, Snippet: "RUN java"},
+		{Meta: shared.FeatureMeta{Name: "curl"}, Snippet: "RUN curl"},
+	})
+	lockfile.Features[1].Snippet = "RUN changed"
+
+	pinned := lockfile.Pinned()
+	if len(pinned) != 1 {
+		t.Fatalf("Pinned() = %v, only unmodified features should be pinned", pinned)
+	}
+	if java := pinned["java"]; java.Snippet != "RUN java" || !reflect.DeepEqual(java.Meta.Dependencies, []string{"curl"}) {
+		t.Errorf("wrong pinned feature: %v", java)
+	}
+}
+
+func TestDiffLockfiles(t *testing.T) {
+	old := Lockfile{Features: []LockedFeature{
+		{Name: "java", Hash: "1"},
+		{Name: "node", Hash: "2"},
+		{Name: "python", Hash: "3", UpdatedAt: "2017-01-01T00:00:00Z"},
+	}}
+	current := Lockfile{Features: []LockedFeature{
+		{Name: "node", Hash: "2"},
+		{Name: "python", Hash: "3", UpdatedAt: "2017-02-01T00:00:00Z"},
+		{Name: "go", Hash: "4"},
+	}}
+
+	var got []string
+	for _, change := range DiffLockfiles(old, current) {
+		got = append(got, change.Change+" "+change.Name)
+	}
+	want := []string{"updated python", "added go", "removed java"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLockfiles() = %v, want %v", got, want)
+	}
+}
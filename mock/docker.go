@@ -0,0 +1,285 @@
+package mock
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// DockerClient is an in-memory Docker daemon for testing the build and test flow. It records
+// the calls made to it and can simulate failing builds and commands.
+type DockerClient struct {
+	// Calls made to the client, e.g. "BuildImage hello" or "StartExec bats -t /pazuzu/test.bats"
+	Calls []string
+
+	// BuildErrors are returned when building the image of the given name.
+	BuildErrors map[string]error
+	// InstructionErrors are returned when building a Dockerfile containing the given instruction.
+	InstructionErrors map[string]error
+	// BuildOutput is written when building the image of the given name, before a build error.
+	BuildOutput map[string]string
+	// ExitCodes of commands run in containers, 0 if not given.
+	ExitCodes map[string]int
+	// Stdout of commands run in containers.
+	Stdout map[string]string
+
+	// Images built or existing, with the Dockerfiles they were built from.
+	Images map[string][]byte
+	// Containers which were created and not removed yet, with the image they were created from.
+	Containers map[string]string
+	// Files uploaded to containers, by path.
+	Files map[string][]byte
+
+	mutex    sync.Mutex
+	running  map[string]bool
+	commands map[string]string
+	ids      int
+}
+
+// NewDockerClient creates a Docker daemon with the given images.
+func NewDockerClient(images ...string) *DockerClient {
+	c := &DockerClient{
+		BuildErrors:       map[string]error{},
+		BuildOutput:       map[string]string{},
+		InstructionErrors: map[string]error{},
+		ExitCodes:         map[string]int{},
+		Stdout:            map[string]string{},
+		Images:            map[string][]byte{},
+		Containers:        map[string]string{},
+		Files:             map[string][]byte{},
+		running:           map[string]bool{},
+		commands:          map[string]string{},
+	}
+	for _, image := range images {
+		c.Images[image] = nil
+	}
+	return c
+}
+
+func (c *DockerClient) record(call string, argument string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.Calls = append(c.Calls, call+" "+argument)
+}
+
+func (c *DockerClient) nextID(prefix string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ids++
+	return fmt.Sprintf("%s-%d", prefix, c.ids)
+}
+
+func (c *DockerClient) BuildImage(opts docker.BuildImageOptions) error {
+	c.record("BuildImage", opts.Name)
+	if output, ok := c.BuildOutput[opts.Name]; ok && opts.OutputStream != nil {
+		io.WriteString(opts.OutputStream, output)
+	}
+	if err := c.BuildErrors[opts.Name]; err != nil {
+		return err
+	}
+	if opts.Context != nil && opts.Context.Err() != nil {
+		return opts.Context.Err()
+	}
+
+	files, err := readArchive(opts.InputStream)
+	if err != nil {
+		return err
+	}
+	for instruction, err := range c.InstructionErrors {
+		if strings.Contains(string(files["Dockerfile"]), instruction) {
+			return err
+		}
+	}
+	c.mutex.Lock()
+	c.Images[opts.Name] = files["Dockerfile"]
+	c.mutex.Unlock()
+
+	if opts.OutputStream != nil {
+		fmt.Fprintf(opts.OutputStream, "Successfully built %s\n", opts.Name)
+	}
+	return nil
+}
+
+func (c *DockerClient) InspectImage(name string) (*docker.Image, error) {
+	c.record("InspectImage", name)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.Images[name]; !ok {
+		return nil, docker.ErrNoSuchImage
+	}
+	return &docker.Image{ID: name}, nil
+}
+
+func (c *DockerClient) RemoveImage(name string) error {
+	c.record("RemoveImage", name)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.Images[name]; !ok {
+		return docker.ErrNoSuchImage
+	}
+	delete(c.Images, name)
+	return nil
+}
+
+func (c *DockerClient) CreateContainer(opts docker.CreateContainerOptions) (*docker.Container, error) {
+	c.record("CreateContainer", opts.Config.Image)
+	c.mutex.Lock()
+	_, ok := c.Images[opts.Config.Image]
+	c.mutex.Unlock()
+	if !ok {
+		return nil, docker.ErrNoSuchImage
+	}
+
+	id := c.nextID("container")
+	c.mutex.Lock()
+	c.Containers[id] = opts.Config.Image
+	c.mutex.Unlock()
+	return &docker.Container{ID: id, Config: opts.Config}, nil
+}
+
+func (c *DockerClient) container(id string) error {
+	if _, ok := c.Containers[id]; !ok {
+		return &docker.NoSuchContainer{ID: id}
+	}
+	return nil
+}
+
+func (c *DockerClient) StartContainer(id string, hostConfig *docker.HostConfig) error {
+	c.record("StartContainer", id)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.container(id); err != nil {
+		return err
+	}
+	c.running[id] = true
+	return nil
+}
+
+func (c *DockerClient) StopContainer(id string, timeout uint) error {
+	c.record("StopContainer", id)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.container(id); err != nil {
+		return err
+	}
+	if !c.running[id] {
+		return &docker.ContainerNotRunning{ID: id}
+	}
+	c.running[id] = false
+	return nil
+}
+
+func (c *DockerClient) KillContainer(opts docker.KillContainerOptions) error {
+	c.record("KillContainer", opts.ID)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.container(opts.ID); err != nil {
+		return err
+	}
+	c.running[opts.ID] = false
+	return nil
+}
+
+func (c *DockerClient) RemoveContainer(opts docker.RemoveContainerOptions) error {
+	c.record("RemoveContainer", opts.ID)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.container(opts.ID); err != nil {
+		return err
+	}
+	if c.running[opts.ID] && !opts.Force {
+		return fmt.Errorf("Container %s is running", opts.ID)
+	}
+	delete(c.Containers, opts.ID)
+	delete(c.running, opts.ID)
+	return nil
+}
+
+func (c *DockerClient) UploadToContainer(id string, opts docker.UploadToContainerOptions) error {
+	c.record("UploadToContainer", id)
+	files, err := readArchive(opts.InputStream)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.container(id); err != nil {
+		return err
+	}
+	for name, contents := range files {
+		c.Files[strings.TrimSuffix(opts.Path, "/")+"/"+name] = contents
+	}
+	return nil
+}
+
+func (c *DockerClient) CreateExec(opts docker.CreateExecOptions) (*docker.Exec, error) {
+	command := opts.Cmd[len(opts.Cmd)-1]
+	c.record("CreateExec", command)
+	c.mutex.Lock()
+	running := c.running[opts.Container]
+	c.mutex.Unlock()
+	if !running {
+		return nil, &docker.ContainerNotRunning{ID: opts.Container}
+	}
+
+	id := c.nextID("exec")
+	c.mutex.Lock()
+	c.commands[id] = command
+	c.mutex.Unlock()
+	return &docker.Exec{ID: id}, nil
+}
+
+func (c *DockerClient) StartExec(id string, opts docker.StartExecOptions) error {
+	c.mutex.Lock()
+	command := c.commands[id]
+	stdout := c.Stdout[command]
+	c.mutex.Unlock()
+	c.record("StartExec", command)
+
+	if opts.OutputStream != nil {
+		io.WriteString(opts.OutputStream, stdout)
+	}
+	return nil
+}
+
+func (c *DockerClient) InspectExec(id string) (*docker.ExecInspect, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	command, ok := c.commands[id]
+	if !ok {
+		return nil, fmt.Errorf("No such exec instance: %s", id)
+	}
+	return &docker.ExecInspect{ID: id, ExitCode: c.ExitCodes[command]}, nil
+}
+
+// readArchive returns the regular files of a tar archive by name.
+func readArchive(reader io.Reader) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if reader == nil {
+		return files, nil
+	}
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		contents, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		files[header.Name] = contents
+	}
+}
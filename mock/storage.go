@@ -1,7 +1,9 @@
 package mock
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
 
 	"github.com/zalando-incubator/pazuzu/shared"
 )
@@ -32,6 +34,90 @@ func (s *TestStorage) SearchMeta(name *regexp.Regexp) ([]shared.FeatureMeta, err
 	return []shared.FeatureMeta{pythonFeatureMeta}, nil
 }
 
+func (s *TestStorage) Search(params shared.SearchParams) ([]shared.FeatureMeta, error) {
+	metas, _ := s.SearchMeta(params.Name)
+	return params.Apply(metas), nil
+}
+
 func (s *TestStorage) Resolve(names ...string) ([]string, map[string]shared.Feature, error) {
-	return []string{}, make(map[string]shared.Feature), nil
+	feature, _ := s.GetFeature(pythonFeatureMeta.Name)
+	return []string{feature.Meta.Name}, map[string]shared.Feature{feature.Meta.Name: feature}, nil
+}
+
+// FeatureStorage is an in-memory StorageReader backed by a fixed set of features.
+type FeatureStorage struct {
+	Features map[string]shared.Feature
+}
+
+func NewFeatureStorage(features ...shared.Feature) *FeatureStorage {
+	s := &FeatureStorage{Features: map[string]shared.Feature{}}
+	for _, feature := range features {
+		s.Features[feature.Meta.Name] = feature
+	}
+	return s
+}
+
+func (s *FeatureStorage) GetFeature(name string) (shared.Feature, error) {
+	feature, ok := s.Features[name]
+	if !ok {
+		return shared.Feature{}, fmt.Errorf("Feature %s not found", name)
+	}
+	return feature, nil
+}
+
+func (s *FeatureStorage) GetMeta(name string) (shared.FeatureMeta, error) {
+	feature, err := s.GetFeature(name)
+	return feature.Meta, err
+}
+
+func (s *FeatureStorage) SearchMeta(name *regexp.Regexp) ([]shared.FeatureMeta, error) {
+	var names []string
+	for featureName := range s.Features {
+		if name.MatchString(featureName) {
+			names = append(names, featureName)
+		}
+	}
+	sort.Strings(names)
+
+	result := []shared.FeatureMeta{}
+	for _, featureName := range names {
+		result = append(result, s.Features[featureName].Meta)
+	}
+	return result, nil
+}
+
+func (s *FeatureStorage) Search(params shared.SearchParams) ([]shared.FeatureMeta, error) {
+	metas, _ := s.SearchMeta(regexp.MustCompile(""))
+	return params.Apply(metas), nil
+}
+
+func (s *FeatureStorage) Resolve(names ...string) ([]string, map[string]shared.Feature, error) {
+	var order []string
+	result := map[string]shared.Feature{}
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if _, ok := result[name]; ok {
+			return nil
+		}
+		feature, err := s.GetFeature(name)
+		if err != nil {
+			return err
+		}
+		result[name] = feature
+		for _, dependency := range feature.Meta.Dependencies {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return []string{}, map[string]shared.Feature{}, err
+		}
+	}
+	return order, result, nil
 }
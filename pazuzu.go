@@ -3,6 +3,10 @@ package pazuzu
 import (
 	"archive/tar"
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/fsouza/go-dockerclient"
 	"gopkg.in/yaml.v2"
@@ -13,11 +17,13 @@ import (
 	"github.com/zalando-incubator/pazuzu/shared"
 	"github.com/zalando-incubator/pazuzu/storageconnector"
 	"os"
-	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
 )
 
 const (
-	tempDir    = "/tmp/pazuzu/"
 	mountPoint = "/pazuzu/"
 	shebang    = "#!/usr/bin/env bats"
 )
@@ -26,6 +32,7 @@ const (
 	PazuzufileName   = "Pazuzufile"
 	DockerfileName   = "Dockerfile"
 	TestSpecFilename = "test.bats"
+	LockfileName     = "Pazuzufile.lock"
 
 	// Default docker endpoint
 	DefaultDockerEndpoint = "unix:///var/run/docker.sock"
@@ -38,18 +45,72 @@ const (
 
 // Pazuzu defines pazuzu config.
 type Pazuzu struct {
-	StorageReader  storageconnector.StorageReader
-	Dockerfile     []byte
-	TestSpec       []byte
-	testSpec       string
-	DockerEndpoint string
-	docker         *docker.Client
-	files          map[string]string
+	StorageReader storageconnector.StorageReader
+	Dockerfile    []byte
+	sections      []string // sections of the generated Dockerfile shown by the build progress
+	TestSpec      []byte   // test file of the bats runner, see TestFiles
+	testSpec      string
+	Docker        DockerOptions
+	DockerClient  DockerClient // connected according to Docker if not set
+	files         map[string]string
+
+	// WithTestDependencies bakes test-only dependencies into the generated Dockerfile.
+	// By default they are installed into a separate image used only for testing.
+	WithTestDependencies bool
+	testFeatures         []shared.Feature
+
+	// TestFiles are the test files of all runners used by the features, the bats one is always there.
+	TestFiles []TestFile
+
+	// SelectedTests are the features whose tests are run, all features if empty.
+	SelectedTests  []string
+	testedFeatures []shared.Feature
+
+	// TestTimeout limits the run time of every test file, the container is killed when it
+	// is exceeded. There is no limit if it is 0.
+	TestTimeout time.Duration
+	// KeepContainer leaves the test container running if tests fail, for debugging.
+	KeepContainer bool
+
+	// TestResults are the results of the tests run by the last DockerBuild or DockerTest.
+	TestResults []FeatureTestResults
+
+	// Lockfile records the features resolved by the last Generate.
+	Lockfile Lockfile
+	// CopySources are the files copied by the features of the last Generate, relative to the
+	// build context. The registry does not provide them, they have to be added to the context.
+	CopySources []string
+
+	// Labels are added to the generated Dockerfile.
+	Labels map[string]string
+	// Snippet is added to the generated Dockerfile after the features.
+	Snippet string
+
+	// Output receives the build and test output, os.Stdout is used if not set.
+	Output io.Writer
+	// Verbosity of the build output, the progress per feature by default.
+	Verbosity Verbosity
+
+	// Bats is a local directory or .tar(.gz) archive of the bats distribution copied to the test
+	// container. If empty, bats has to be installed in the image.
+	Bats string
 }
 
 type PazuzuFile struct {
-	Base     string
-	Features []string
+	Base        string   `yaml:"base"`
+	Features    []string `yaml:"features"`
+	Author      string   `yaml:"author,omitempty"`
+	License     string   `yaml:"license,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Maintainers []string `yaml:"maintainers,omitempty"`
+	Snippet     string   `yaml:"snippet,omitempty"`
+}
+
+func (p *Pazuzu) output() io.Writer {
+	if p.Output == nil {
+		return os.Stdout
+	}
+	return p.Output
 }
 
 func MakeShellCommand(command string) []string {
@@ -90,78 +151,289 @@ func Write(writer io.Writer, pazuzuFile PazuzuFile) error {
 
 // Generate generates Dockfiler and test.spec file base on list of features
 func (p *Pazuzu) Generate(baseimage string, features []string) error {
+	if err := CheckFeatures(p.StorageReader, features); err != nil {
+		return err
+	}
+	featureNamesWithDep, featuresMap, err := p.StorageReader.Resolve(features...)
+	if err != nil {
+		return err
+	}
+
+	// virtual features are replaced by their resolved providers
 	var resolvedFeatures []string
 	for _, feature := range features {
-		// TODO: add proper error handling
-		repoFeature, _ := p.StorageReader.GetFeature(feature)
-		resolvedFeatures = append(resolvedFeatures, repoFeature.Meta.Name)
+		name, ok := shared.LookupDependency(featuresMap, feature)
+		if !ok {
+			return fmt.Errorf("Feature %s not found", feature)
+		}
+		resolvedFeatures = append(resolvedFeatures, name)
+	}
+
+	if err := shared.CheckConflicts(resolvedFeatures, featuresMap); err != nil {
+		return err
 	}
-	// TODO: add proper error handling
-	var featureNamesWithDep []string
-	featureNamesWithDep, featuresMap, _ := p.StorageReader.Resolve(resolvedFeatures...)
+
 	featuresWithDep := make([]shared.Feature, 0, len(featuresMap))
 
 	for _, featureName := range featureNamesWithDep {
 		featuresWithDep = append(featuresWithDep, featuresMap[featureName])
 	}
 
-	err := p.generateDockerfile(baseimage, featuresWithDep)
+	testFeatures, err := p.resolveTestDependencies(featureNamesWithDep, featuresMap)
+	if err != nil {
+		return err
+	}
+
+	p.testFeatures = testFeatures
+	imageFeatures := featuresWithDep
+	if p.WithTestDependencies {
+		p.testFeatures = nil
+		imageFeatures = append(imageFeatures, testFeatures...)
+	}
+
+	p.Lockfile = NewLockfile(append(append([]shared.Feature{}, imageFeatures...), p.testFeatures...))
+
+	err = p.generateDockerfile(baseimage, imageFeatures)
 	if err != nil {
 		return err
 	}
 
+	p.testedFeatures = featuresWithDep
 	if err := p.generateTestSpec(featuresWithDep); err != nil {
 		return err
 	}
 
+	p.Dockerfile = AddGeneratedHeader(p.Dockerfile)
+
 	return nil
 }
 
+// resolveTestDependencies resolves features required only by the tests of the given features.
+// Returns the features which are not part of the already resolved ones in installation order.
+func (p *Pazuzu) resolveTestDependencies(names []string, features map[string]shared.Feature) ([]shared.Feature, error) {
+	var testDependencies []string
+	for _, name := range names {
+		for _, dependency := range features[name].Meta.TestDependencies {
+			if _, ok := shared.LookupDependency(features, dependency); !ok && !contains(testDependencies, dependency) {
+				testDependencies = append(testDependencies, dependency)
+			}
+		}
+	}
+	if len(testDependencies) == 0 {
+		return nil, nil
+	}
+
+	requested := make([]string, 0, len(names)+len(testDependencies))
+	requested = append(append(requested, names...), testDependencies...)
+
+	order, resolved, err := p.StorageReader.Resolve(requested...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := shared.CheckConflicts(requested, resolved); err != nil {
+		return nil, err
+	}
+
+	var testFeatures []shared.Feature
+	for _, name := range order {
+		if _, ok := features[name]; !ok {
+			testFeatures = append(testFeatures, resolved[name])
+		}
+	}
+	return testFeatures, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
 // generate in-memory Dockerfile from list of features.
 func (p *Pazuzu) generateDockerfile(baseimage string, features []shared.Feature) error {
+	dockerfile, sources, err := renderDockerfile(baseimage, p.Labels, features, p.Snippet)
+	if err != nil {
+		return err
+	}
+
+	p.Dockerfile = dockerfile
+	p.CopySources = sources
+	p.sections = sectionNames(features, p.Snippet)
+
+	return nil
+}
+
+// labelQuoter escapes the characters which are special in double quoted Dockerfile values.
+var labelQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// quoteLabel quotes a LABEL value the way Dockerfiles do, other characters are kept as they are.
+func quoteLabel(value string) string {
+	return `"` + labelQuoter.Replace(value) + `"`
+}
+
+// renderDockerfile returns the Dockerfile installing the features and the files copied by them.
+func renderDockerfile(baseimage string, labels map[string]string, features []shared.Feature, snippet string) ([]byte, []string, error) {
 	writer := NewDockerfileWriter()
 
 	err := writer.AppendRaw(fmt.Sprintf("FROM %s\n", baseimage))
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	if len(labels) > 0 {
+		keys := make([]string, 0, len(labels))
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", key, quoteLabel(labels[key])))
+		}
+		err = writer.AppendRaw(fmt.Sprintf("LABEL %s\n", strings.Join(pairs, " ")))
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	for _, feature := range features {
 		err = writer.AppendRaw(fmt.Sprintf("# %s\n", feature.Meta.Name))
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 
 		err = writer.AppendFeature(feature)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
 
-	err = writer.AppendRaw("CMD /bin/bash\n")
-	if err != nil {
-		return err
+	if snippet != "" {
+		err = writer.AppendRaw(fmt.Sprintf("# %s\n%s", PazuzufileName, strings.TrimRight(snippet, "\n")))
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	p.Dockerfile = writer.Bytes()
+	// the command gets its own section, so that it is not taken for part of the last feature
+	err = writer.AppendRaw(fmt.Sprintf("# %s\nCMD /bin/bash\n", commandSection))
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return nil
+	return writer.Bytes(), writer.CopySources(), nil
 }
 
 // DockerBuild builds a docker image based on the generated Dockerfile.
+// If there are test-only dependencies, the image is tested within a temporary
+// image built on top of it, so the test tooling does not end up in the result.
 func (p *Pazuzu) DockerBuild(name string) error {
-	client, err := docker.NewClient(p.DockerEndpoint)
+	err := p.connectDocker()
 	if err != nil {
 		return fmt.Errorf("Error: %s", err)
+	}
+
+	ctx, stopWatching := watchInterrupt()
+	defer stopWatching()
+
+	err = p.buildImage(ctx, name, p.Dockerfile, p.sections)
+	if err != nil {
+		return err
+	}
+
+	return p.dockerTest(ctx, name)
+}
+
+// DockerTest runs the tests of the generated features against an existing image, given by
+// name or ID. Test-only dependencies are installed into a temporary image built on top of it.
+func (p *Pazuzu) DockerTest(image string) error {
+	err := p.connectDocker()
+	if err != nil {
+		return fmt.Errorf("Error: %s", err)
+	}
+
+	ctx, stopWatching := watchInterrupt()
+	defer stopWatching()
+	return p.dockerTest(ctx, image)
+}
+
+// dockerTest tests the image, the build of the test image and the tests are ended when the
+// context is cancelled.
+func (p *Pazuzu) dockerTest(ctx context.Context, image string) (err error) {
+	client := p.DockerClient
+	if _, err := client.InspectImage(image); err != nil {
+		return fmt.Errorf("Image %s not found: %s", image, err)
+	}
+
+	if len(p.testFeatures) == 0 {
+		return p.testDockerImage(ctx, image)
+	}
+
+	run, err := runID()
+	if err != nil {
+		return err
+	}
+	testImage := testImageName(image, run)
+	testDockerfile, _, err := renderDockerfile(image, nil, p.testFeatures, "")
+	if err != nil {
 		return err
 	}
 
+	// the test image is unique to this run, so it is removed on every path, also if its
+	// build failed or was interrupted after it was tagged
+	defer func() {
+		errRemove := client.RemoveImage(testImage)
+		if errRemove != nil && errRemove != docker.ErrNoSuchImage && err == nil {
+			err = errRemove
+		}
+	}()
+
+	err = p.buildImage(ctx, testImage, testDockerfile, sectionNames(p.testFeatures, ""))
+	if err != nil {
+		return err
+	}
+
+	return p.testDockerImage(ctx, testImage)
+}
+
+var imageID = regexp.MustCompile(`^(?:sha256:)?([0-9a-f]{12,64})$`)
+
+// testImageName returns the name of the image with test-only dependencies for the given image.
+// The name contains the ID of the test run, so that parallel runs testing the same image do not collide.
+func testImageName(name string, run string) string {
+	// IDs and digests can not be extended to a tag
+	if match := imageID.FindStringSubmatch(name[strings.LastIndex(name, "@")+1:]); match != nil {
+		return "pazuzu-test:" + match[1][:12] + "-" + run
+	}
+	if strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		return name + "-test-" + run
+	}
+	return name + ":test-" + run
+}
+
+// runID returns a random ID of a test run.
+func runID() (string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// buildImage builds the Dockerfile and shows the progress according to the Verbosity.
+// The build is ended when the context is cancelled.
+func (p *Pazuzu) buildImage(ctx context.Context, name string, dockerfile []byte, sections []string) error {
 	t := time.Now()
 	inputBuf := bytes.NewBuffer(nil)
 	tr := tar.NewWriter(inputBuf)
-	err = tr.WriteHeader(&tar.Header{
+	err := tr.WriteHeader(&tar.Header{
 		Name:       DockerfileName,
-		Size:       int64(len(p.Dockerfile)),
+		Size:       int64(len(dockerfile)),
 		ModTime:    t,
 		AccessTime: t,
 		ChangeTime: t,
@@ -170,7 +442,7 @@ func (p *Pazuzu) DockerBuild(name string) error {
 		return err
 	}
 
-	_, err = tr.Write(p.Dockerfile)
+	_, err = tr.Write(dockerfile)
 	if err != nil {
 		return err
 	}
@@ -180,103 +452,106 @@ func (p *Pazuzu) DockerBuild(name string) error {
 		return err
 	}
 
+	progress := newBuildProgress(p.output(), p.Verbosity, dockerfile, sections)
 	opts := docker.BuildImageOptions{
 		Name:         name,
 		InputStream:  inputBuf,
-		OutputStream: os.Stdout,
+		OutputStream: progress,
+		Context:      ctx,
 	}
 
-	err2 := client.BuildImage(opts)
-	if err2 != nil {
-		err = fmt.Errorf("Error: %s", err2)
-		return err
+	err = progress.finish(p.DockerClient.BuildImage(opts))
+	if err != nil && ctx.Err() != nil {
+		return errors.New("Build interrupted")
 	}
-
-	err = p.testDockerImage(name)
-
 	return err
 }
 
-func (p *Pazuzu) dockerExec(ID string, cmd string) error {
+// dockerExec runs the command in the container. No TTY is used, so that stdout and stderr
+// of the command are kept apart.
+func (p *Pazuzu) dockerExec(ID string, cmd string, stdout io.Writer, stderr io.Writer) error {
 	execOpts := docker.CreateExecOptions{
 		Container:    ID,
 		AttachStdin:  false,
 		AttachStdout: true,
 		AttachStderr: true,
 		Cmd:          MakeShellCommand(cmd),
-		Tty:          true,
 	}
-	exec, err := p.docker.CreateExec(execOpts)
+	exec, err := p.DockerClient.CreateExec(execOpts)
 	if err != nil {
 		return err
 	}
 
-	var buf bytes.Buffer
-	var errBuf bytes.Buffer
-
 	startExecOpts := docker.StartExecOptions{
 		Detach:       false,
-		OutputStream: os.Stdout,
-		ErrorStream:  &errBuf,
-		RawTerminal:  true,
-		Tty:          true,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
 	}
 
-	err = p.docker.StartExec(exec.ID, startExecOpts)
+	err = p.DockerClient.StartExec(exec.ID, startExecOpts)
 	if err != nil {
 		return err
 	}
 
-	inspect, err := p.docker.InspectExec(exec.ID)
+	inspect, err := p.DockerClient.InspectExec(exec.ID)
 	if err != nil {
 		return err
 	}
 
 	if inspect.ExitCode > 0 {
-		return fmt.Errorf("exit code %d: %s", inspect.ExitCode, buf.String())
+		return fmt.Errorf("exit code %d", inspect.ExitCode)
 	}
 
 	return nil
 }
 
-func (p *Pazuzu) dockerStart(image string) (*docker.Container, error) {
-	var err error
-	p.docker, err = docker.NewClient(p.DockerEndpoint)
+// connectDocker connects to the Docker daemon, unless a DockerClient is set already.
+func (p *Pazuzu) connectDocker() error {
+	if p.DockerClient != nil {
+		return nil
+	}
+	client, err := NewDockerClient(p.Docker)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	p.DockerClient = client
+	return nil
+}
+
+func (p *Pazuzu) dockerStart(image string) (*docker.Container, error) {
 	opts := docker.CreateContainerOptions{
 		Config: &docker.Config{
 			Image: image,
 			Tty:   true,
 			Cmd:   MakeShellCommand(NoShellCommand),
 		},
-		HostConfig: &docker.HostConfig{
-			Binds: []string{
-				tempDir + ":" + mountPoint,
-			},
-		},
 	}
 
-	container, err := p.docker.CreateContainer(opts)
+	container, err := p.DockerClient.CreateContainer(opts)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := p.docker.StartContainer(container.ID, nil); err != nil {
+	if err := p.DockerClient.StartContainer(container.ID, nil); err != nil {
+		p.dockerStop(container.ID)
 		return nil, err
 	}
 
 	return container, nil
 }
 
+// dockerStop stops and removes the container, which may have been stopped or killed already.
 func (p *Pazuzu) dockerStop(ID string) error {
-	if err := p.docker.StopContainer(ID, 1); err != nil {
-		return err
+	if err := p.DockerClient.StopContainer(ID, 1); err != nil {
+		if _, ok := err.(*docker.ContainerNotRunning); !ok {
+			return err
+		}
 	}
 
-	if err := p.docker.RemoveContainer(docker.RemoveContainerOptions{
-		ID: ID,
+	if err := p.DockerClient.RemoveContainer(docker.RemoveContainerOptions{
+		ID:            ID,
+		RemoveVolumes: true,
+		Force:         true,
 	}); err != nil {
 		return err
 	}
@@ -284,65 +559,233 @@ func (p *Pazuzu) dockerStop(ID string) error {
 	return nil
 }
 
+// generateTestSpec generates a test file for every test runner used by the features.
 func (p *Pazuzu) generateTestSpec(features []shared.Feature) error {
-	var buffer = bytes.NewBufferString("")
-	if err := WriteTestSpec(buffer, features); err != nil {
-		return err
+	byRunner := map[string][]shared.Feature{}
+	for _, feature := range features {
+		name := TestRunnerName(feature)
+		byRunner[name] = append(byRunner[name], feature)
 	}
-	p.TestSpec = buffer.Bytes()
+	for name := range byRunner {
+		if _, err := NewTestRunner(name, p.Bats); err != nil {
+			return err
+		}
+	}
+
+	p.TestFiles = nil
+	for _, name := range TestRunnerNames {
+		if _, ok := byRunner[name]; !ok && name != TestRunnerBats {
+			continue
+		}
+		runner, _ := NewTestRunner(name, p.Bats)
+
+		var buffer bytes.Buffer
+		if err := runner.WriteTestSpec(&buffer, byRunner[name]); err != nil {
+			return err
+		}
+		p.TestFiles = append(p.TestFiles, TestFile{
+			Filename: runner.Filename(),
+			Runner:   name,
+			Contents: AddGeneratedHeader(buffer.Bytes()),
+		})
+	}
+	p.TestSpec = p.TestFiles[0].Contents
 	return nil
 }
 
-func (p *Pazuzu) testDockerImage(image string) error {
-	os.MkdirAll(tempDir, 0777)
+// selectedFeatures returns the features whose tests are run, see SelectedTests.
+func (p *Pazuzu) selectedFeatures() ([]shared.Feature, error) {
+	if len(p.SelectedTests) == 0 {
+		return p.testedFeatures, nil
+	}
+
+	var features []shared.Feature
+	for _, name := range p.SelectedTests {
+		found := false
+		for _, feature := range p.testedFeatures {
+			if feature.Meta.Name == name {
+				features = append(features, feature)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Feature %s is not part of the project", name)
+		}
+	}
+	return features, nil
+}
+
+// testFiles returns the test files to run, one for every feature with tests, so that the
+// tests of a feature can not break the tests of others. If the tests were not generated,
+// TestSpec is run with bats.
+func (p *Pazuzu) testFiles() ([]TestFile, error) {
+	if len(p.TestFiles) == 0 {
+		return []TestFile{{Filename: TestSpecFilename, Runner: TestRunnerBats, Contents: p.TestSpec}}, nil
+	}
 
-	batsZip := tempDir + "master.zip"
+	features, err := p.selectedFeatures()
+	if err != nil {
+		return nil, err
+	}
 
-	if err := exec.Command(
-		"wget",
-		"https://github.com/sstephenson/bats/archive/master.zip",
-		"-O", batsZip).Run(); err != nil {
-		fmt.Println("Couldn't download bats")
-		return err
+	var files []TestFile
+	for _, feature := range features {
+		if strings.TrimSpace(feature.TestSnippet) == "" {
+			continue
+		}
+		name := TestRunnerName(feature)
+		runner, err := NewTestRunner(name, p.Bats)
+		if err != nil {
+			return nil, err
+		}
+		var buffer bytes.Buffer
+		if err := runner.WriteTestSpec(&buffer, []shared.Feature{feature}); err != nil {
+			return nil, err
+		}
+		files = append(files, TestFile{
+			Filename: path.Join("tests", feature.Meta.Name, runner.Filename()),
+			Runner:   name,
+			Contents: buffer.Bytes(),
+			Feature:  feature.Meta.Name,
+		})
+	}
+	return files, nil
+}
+
+// runTestFile runs a test file in the container and parses its TAP output. A failing
+// test file without any failed test, e.g. because of a syntax error, is reported as a failed test.
+func (p *Pazuzu) runTestFile(container *testContainer, file TestFile) ([]TestResult, error) {
+	runner, err := NewTestRunner(file.Runner, p.Bats)
+	if err != nil {
+		return nil, err
 	}
-	if err := exec.Command("unzip", "-o", batsZip, "-d", tempDir).Run(); err != nil {
-		fmt.Println("Couldn't unzip bats to " + tempDir)
-		return err
+
+	timer := container.killAfter(p.TestTimeout, "Tests in "+file.Filename)
+	var tap bytes.Buffer
+	err = p.dockerExec(container.ID, runner.Command(mountPoint+file.Filename), io.MultiWriter(p.output(), &tap), p.output())
+	if timer != nil {
+		timer.Stop()
+	}
+	if reason := container.killed(); reason != "" {
+		err = fmt.Errorf("Container was killed: %s", reason)
+	}
+
+	results, errParse := ParseTAP(&tap)
+	if errParse != nil && err == nil {
+		err = errParse
 	}
-	if err := exec.Command("rm", batsZip).Run(); err != nil {
-		fmt.Println("Couldn't delete master.zip")
+
+	failed := false
+	for _, result := range results {
+		failed = failed || result.Status == TestFailed
+	}
+	if results == nil {
+		results = []TestResult{}
+	}
+	if err != nil && !failed {
+		results = append(results, TestResult{
+			Number:      len(results) + 1,
+			Name:        file.Filename,
+			Status:      TestFailed,
+			Diagnostics: err.Error(),
+		})
+	}
+	return results, err
+}
+
+// testDockerImage runs the tests in a container of the image. The container is removed
+// on every path, unless KeepContainer is set and tests failed.
+func (p *Pazuzu) testDockerImage(ctx context.Context, image string) (err error) {
+	testFiles, err := p.testFiles()
+	if err != nil {
 		return err
 	}
-	if err := exec.Command("cp", TestSpecFilename, tempDir).Run(); err != nil {
-		fmt.Println("Couldn't copy test.bats file to " + tempDir)
+	archive, err := testArchive(testFiles, p.Bats)
+	if err != nil {
+		fmt.Fprintln(p.output(), "Couldn't prepare tests")
 		return err
 	}
 
-	container, err := p.dockerStart(image)
+	started, err := p.dockerStart(image)
 	if err != nil {
-		fmt.Println("Couldn't start docker container")
-		fmt.Println(err)
+		fmt.Fprintln(p.output(), "Couldn't start docker container")
+		fmt.Fprintln(p.output(), err)
 		return err
 	}
+	container := &testContainer{client: p.DockerClient, ID: started.ID}
+	stopWatching := container.killOnCancel(ctx)
+
+	defer func() {
+		stopWatching()
+		if p.KeepContainer && err != nil && container.killed() != interrupted {
+			fmt.Fprintf(p.output(), "Keeping container %s for debugging, remove it with `docker rm -f %s`\n",
+				container.ID, container.ID)
+			return
+		}
+		if errStop := p.dockerStop(container.ID); errStop != nil {
+			fmt.Fprintln(p.output(), "Couldn't stop container")
+			if err == nil {
+				err = errStop
+			}
+		}
+	}()
 
-	if err := p.dockerExec(
-		container.ID,
-		fmt.Sprintf("%sbats-master/install.sh /usr/local && /usr/local/bin/bats -p %s%s", mountPoint, mountPoint, TestSpecFilename)); err != nil {
-		fmt.Println("Couldn't exec test commands on container")
-		fmt.Println(err)
+	if err := p.DockerClient.UploadToContainer(container.ID, docker.UploadToContainerOptions{
+		InputStream: bytes.NewReader(archive),
+		Path:        "/",
+	}); err != nil {
+		fmt.Fprintln(p.output(), "Couldn't copy tests to container")
+		fmt.Fprintln(p.output(), err)
 		return err
 	}
 
-	if err := p.dockerStop(container.ID); err != nil {
-		fmt.Println("Couldn't stop container")
+	if err := p.checkBats(container.ID, testFiles); err != nil {
 		return err
 	}
 
-	if err := os.RemoveAll(tempDir); err != nil {
-		fmt.Println("Couldn't delete " + tempDir)
-		return err
+	// all the test files are run, even if some of them fail
+	var results []FeatureTestResults
+	var failed []string
+	for _, file := range testFiles {
+		var tests []TestResult
+		if reason := container.killed(); reason != "" {
+			tests = []TestResult{{Number: 1, Name: file.Filename, Status: TestFailed, Diagnostics: "Not run: " + reason}}
+			failed = append(failed, file.Filename)
+		} else if tests, err = p.runTestFile(container, file); err != nil {
+			fmt.Fprintf(p.output(), "Tests in %s failed: %s\n", file.Filename, err)
+			failed = append(failed, file.Filename)
+		}
+		results = append(results, FeatureTestResults{Feature: file.Feature, File: file.Filename, Tests: tests})
 	}
+	features, _ := p.selectedFeatures()
+	p.TestResults = withUntestedFeatures(features, results)
+	WriteTestSummary(p.output(), p.TestResults)
 
+	if container.killed() == interrupted {
+		return errors.New("Tests interrupted")
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Tests failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// checkBats fails if bats tests are run without a bats distribution and bats is not installed
+// in the image either, instead of every bats test failing with "command not found".
+func (p *Pazuzu) checkBats(containerID string, testFiles []TestFile) error {
+	if p.Bats != "" {
+		return nil
+	}
+	for _, file := range testFiles {
+		if file.Runner != "" && file.Runner != TestRunnerBats {
+			continue
+		}
+		if err := p.dockerExec(containerID, batsCheck, ioutil.Discard, ioutil.Discard); err != nil {
+			return errors.New("Bats is not installed in the image, install it by a test dependency or " +
+				"use a local bats distribution with `pazuzu config set bats <directory or .tar.gz>`")
+		}
+		return nil
+	}
 	return nil
 }
 
@@ -350,7 +793,7 @@ func WriteTestSpec(writer io.Writer, features []shared.Feature) error {
 	var lines = []string{shebang}
 
 	for _, feature := range features {
-		lines = append(lines, feature.TestSnippet)
+		lines = append(lines, fmt.Sprintf("# %s\n%s", feature.Meta.Name, feature.TestSnippet))
 	}
 
 	for _, line := range lines {
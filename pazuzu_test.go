@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/zalando-incubator/pazuzu/mock"
+	"github.com/zalando-incubator/pazuzu/shared"
+	"github.com/zalando-incubator/pazuzu/storageconnector"
 	"io/ioutil"
 )
 
@@ -59,7 +61,7 @@ func TestWrite(t *testing.T) {
 // Test building a generated Dockerfile.
 func TestDockerBuild(t *testing.T) {
 	pazuzu := Pazuzu{
-		DockerEndpoint: "unix:///var/run/docker.sock",
+		Docker: DockerOptions{Endpoint: "unix:///var/run/docker.sock"},
 		Dockerfile: []byte(`FROM ubuntu:latest
 RUN apt-get update && apt-get install python --yes`),
 		TestSpec: []byte(`#!/usr/bin/env bats
@@ -79,3 +81,145 @@ RUN apt-get update && apt-get install python --yes`),
 		t.Errorf("should not fail: %s", err)
 	}
 }
+
+// Test that test-only dependencies are kept out of the generated Dockerfile unless requested.
+func TestGenerateTestDependencies(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{
+			Meta:    shared.FeatureMeta{Name: "python", TestDependencies: []string{"pytest"}},
+			Snippet: "RUN apt-get install python --yes",
+		},
+		shared.Feature{
+			Meta:    shared.FeatureMeta{Name: "pytest"},
+			Snippet: "RUN pip install pytest",
+		},
+	)
+
+	pazuzu := Pazuzu{StorageReader: storage}
+	err := pazuzu.Generate("ubuntu", []string{"python"})
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if strings.Contains(string(pazuzu.Dockerfile), "pytest") {
+		t.Errorf("test dependencies should not be installed: %s", pazuzu.Dockerfile)
+	}
+	if len(pazuzu.testFeatures) != 1 || pazuzu.testFeatures[0].Meta.Name != "pytest" {
+		t.Errorf("test dependencies should be resolved: %v", pazuzu.testFeatures)
+	}
+
+	pazuzu = Pazuzu{StorageReader: storage, WithTestDependencies: true}
+	err = pazuzu.Generate("ubuntu", []string{"python"})
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if !strings.Contains(string(pazuzu.Dockerfile), "RUN pip install pytest") {
+		t.Errorf("test dependencies should be installed: %s", pazuzu.Dockerfile)
+	}
+}
+
+func TestGenerateSnippet(t *testing.T) {
+	storage := mock.NewFeatureStorage(shared.Feature{
+		Meta:    shared.FeatureMeta{Name: "python"},
+		Snippet: "RUN apt-get install python --yes",
+	})
+
+	pazuzu := Pazuzu{StorageReader: storage, Snippet: "ENV APP_HOME /app\n"}
+	err := pazuzu.Generate("ubuntu", []string{"python"})
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := "# python\n\nRUN apt-get install python --yes\n# Pazuzufile\nENV APP_HOME /app\n# default command\nCMD /bin/bash\n"
+	if !strings.Contains(string(pazuzu.Dockerfile), want) {
+		t.Errorf("snippet should be added after the features:\n%s", pazuzu.Dockerfile)
+	}
+	if generated, modified := CheckGenerated(pazuzu.Dockerfile); !generated || modified {
+		t.Errorf("Dockerfile should be marked as generated:\n%s", pazuzu.Dockerfile)
+	}
+}
+
+func TestTestImageName(t *testing.T) {
+	tests := map[string]string{
+		"hello":                   "hello:test-1a2b",
+		"hello:1.0":               "hello:1.0-test-1a2b",
+		"localhost:5000/hello":    "localhost:5000/hello:test-1a2b",
+		"localhost:5000/hello:v1": "localhost:5000/hello:v1-test-1a2b",
+		"0123456789ab":            "pazuzu-test:0123456789ab-1a2b",
+		"sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef":       "pazuzu-test:0123456789ab-1a2b",
+		"hello@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef": "pazuzu-test:0123456789ab-1a2b",
+	}
+	for name, want := range tests {
+		if got := testImageName(name, "1a2b"); got != want {
+			t.Errorf("testImageName(%s) = %s, want %s", name, got, want)
+		}
+	}
+
+	first, _ := runID()
+	second, _ := runID()
+	if len(first) != 8 || first == second {
+		t.Errorf("run IDs should be random: %s, %s", first, second)
+	}
+}
+
+func TestSelectedFeatures(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "python"}, TestSnippet: "@test \"python\" {\n}"},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node"}, TestSnippet: "@test \"node\" {\n}"},
+	)
+
+	pazuzu := Pazuzu{StorageReader: storage, SelectedTests: []string{"node"}}
+	if err := pazuzu.Generate("ubuntu", []string{"python", "node"}); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	files, err := pazuzu.testFiles()
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if len(files) != 1 || files[0].Feature != "node" {
+		t.Errorf("only tests of node should be run: %v", files)
+	}
+
+	pazuzu.SelectedTests = []string{"ruby"}
+	if _, err := pazuzu.testFiles(); err == nil {
+		t.Error("selecting a feature which is not part of the project should fail")
+	}
+}
+
+func TestGenerateFeatureLookup(t *testing.T) {
+	features := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "python"}, Snippet: "RUN apt-get install python --yes"},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "openjdk", Provides: []string{"jdk"}}, Snippet: "RUN apt-get install openjdk-8-jdk --yes"},
+	)
+
+	tests := []struct {
+		name     string
+		features []string
+		defaults map[string]string
+		want     string
+		err      string
+	}{
+		{"Virtual feature", []string{"jdk"}, map[string]string{"jdk": "openjdk"}, "openjdk-8-jdk", ""},
+		{"Virtual feature without default", []string{"jdk"}, nil, "",
+			"Feature jdk is virtual, add one of its providers to the project or configure a default one: openjdk"},
+		{"Typo", []string{"pythn"}, nil, "", "Feature pythn not found, did you mean: python?"},
+		{"Unknown feature", []string{"cobol"}, nil, "", "Feature cobol not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pazuzu := Pazuzu{StorageReader: storageconnector.NewProviderStorage(features, tt.defaults)}
+			err := pazuzu.Generate("ubuntu", tt.features)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("Generate() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("should not fail: %s", err)
+			}
+			if !strings.Contains(string(pazuzu.Dockerfile), tt.want) {
+				t.Errorf("Dockerfile should contain %q:\n%s", tt.want, pazuzu.Dockerfile)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package pazuzu
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SettingKeys returns the keys of all Pazuzufile settings, as used by `project show/set`.
+func SettingKeys() []string {
+	fileType := reflect.TypeOf(PazuzuFile{})
+	keys := make([]string, 0, fileType.NumField())
+	for i := 0; i < fileType.NumField(); i++ {
+		keys = append(keys, settingKey(fileType.Field(i)))
+	}
+	return keys
+}
+
+// GetSetting returns the value of the setting, lists are comma separated.
+func (f *PazuzuFile) GetSetting(key string) (string, error) {
+	field, ok := f.settingField(key)
+	if !ok {
+		return "", ErrUnknownSetting
+	}
+	if field.Kind() == reflect.Slice {
+		return strings.Join(field.Interface().([]string), ","), nil
+	}
+	return field.String(), nil
+}
+
+// SetSetting sets the value of the setting, lists are given comma separated.
+func (f *PazuzuFile) SetSetting(key string, value string) error {
+	field, ok := f.settingField(key)
+	if !ok {
+		return ErrUnknownSetting
+	}
+	if field.Kind() != reflect.Slice {
+		field.SetString(value)
+		return nil
+	}
+
+	var values []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			values = append(values, element)
+		}
+	}
+	field.Set(reflect.ValueOf(values))
+	return nil
+}
+
+// Labels returns the project settings to be set as Docker image labels.
+func (f *PazuzuFile) Labels() map[string]string {
+	labels := map[string]string{}
+	for key, value := range map[string]string{
+		"author":      f.Author,
+		"license":     f.License,
+		"description": f.Description,
+		"maintainer":  strings.Join(f.Maintainers, ", "),
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+func (f *PazuzuFile) settingField(key string) (reflect.Value, bool) {
+	value := reflect.ValueOf(f).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if settingKey(value.Type().Field(i)) == key {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func settingKey(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("yaml"), ",")[0]
+}
@@ -0,0 +1,69 @@
+package pazuzu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu/mock"
+)
+
+func TestSettingKeys(t *testing.T) {
+	want := []string{"base", "features", "author", "license", "description", "maintainers", "snippet"}
+	if keys := SettingKeys(); !reflect.DeepEqual(keys, want) {
+		t.Errorf("SettingKeys() = %v, want %v", keys, want)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	pazuzuFile := &PazuzuFile{Base: "ubuntu"}
+
+	tests := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{"base", "debian", "debian"},
+		{"author", "Jane Doe", "Jane Doe"},
+		{"maintainers", "jane@example.org, john@example.org,", "jane@example.org,john@example.org"},
+		{"features", "", ""},
+	}
+	for _, tt := range tests {
+		if err := pazuzuFile.SetSetting(tt.key, tt.value); err != nil {
+			t.Fatalf("SetSetting(%s) should not fail: %s", tt.key, err)
+		}
+		if value, err := pazuzuFile.GetSetting(tt.key); err != nil || value != tt.want {
+			t.Errorf("GetSetting(%s) = %q, %v, want %q", tt.key, value, err, tt.want)
+		}
+	}
+	if !reflect.DeepEqual(pazuzuFile.Maintainers, []string{"jane@example.org", "john@example.org"}) {
+		t.Errorf("wrong maintainers: %v", pazuzuFile.Maintainers)
+	}
+
+	if err := pazuzuFile.SetSetting("unknown", "value"); err != ErrUnknownSetting {
+		t.Errorf("SetSetting(unknown) = %v, want %v", err, ErrUnknownSetting)
+	}
+	if _, err := pazuzuFile.GetSetting("unknown"); err != ErrUnknownSetting {
+		t.Errorf("GetSetting(unknown) = %v, want %v", err, ErrUnknownSetting)
+	}
+}
+
+func TestGenerateLabels(t *testing.T) {
+	pazuzuFile := &PazuzuFile{
+		Author:      "Jane Doe",
+		License:     "MIT",
+		Description: "Café \"Zürich\"\tC:\\app",
+		Maintainers: []string{"a@example.org", "b@example.org"},
+	}
+	pazuzu := Pazuzu{StorageReader: mock.NewFeatureStorage(), Labels: pazuzuFile.Labels()}
+
+	err := pazuzu.Generate("ubuntu", nil)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	want := "FROM ubuntu\n\nLABEL author=\"Jane Doe\" description=\"Café \\\"Zürich\\\"\tC:\\\\app\" license=\"MIT\" " +
+		"maintainer=\"a@example.org, b@example.org\"\n"
+	if !strings.HasPrefix(string(StripGeneratedHeader(pazuzu.Dockerfile)), want) {
+		t.Errorf("Dockerfile should start with labels, got:\n%s", pazuzu.Dockerfile)
+	}
+}
@@ -0,0 +1,199 @@
+package pazuzu
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+// Verbosity of the build progress.
+type Verbosity int
+
+const (
+	// VerbosityNormal shows the progress per feature.
+	VerbosityNormal Verbosity = iota
+	// VerbosityQuiet shows nothing but errors.
+	VerbosityQuiet
+	// VerbosityVerbose shows the output of docker build in addition.
+	VerbosityVerbose
+)
+
+// Sections of the Dockerfile which are not features: the part before the first feature and
+// the command after all features. Feature names have no spaces, so they can not clash.
+const (
+	baseSection    = "base image"
+	commandSection = "default command"
+)
+
+var buildStep = regexp.MustCompile(`^Step (\d+)(?:/\d+)? : (.*)$`)
+
+// sectionNames returns the sections written by Generate in front of the instructions of every
+// feature, of the Pazuzufile snippet and of the command, in the order of the Dockerfile.
+func sectionNames(features []shared.Feature, snippet string) []string {
+	var names []string
+	for _, feature := range features {
+		names = append(names, feature.Meta.Name)
+	}
+	if snippet != "" {
+		names = append(names, PazuzufileName)
+	}
+	return append(names, commandSection)
+}
+
+// dockerfileSections returns the section of every instruction of the Dockerfile. The sections
+// start at the `# name` comments of the given names in that order, other comments are ignored.
+func dockerfileSections(dockerfile []byte, names []string) []string {
+	var sections []string
+	section := baseSection
+	continued := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(dockerfile))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			if !continued && len(names) > 0 && strings.TrimSpace(line[1:]) == names[0] {
+				section, names = names[0], names[1:]
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if !continued {
+			sections = append(sections, section)
+		}
+		continued = strings.HasSuffix(line, "\\")
+	}
+	return sections
+}
+
+// buildProgress parses the output of docker build into steps and shows the progress of
+// every feature with the time it took to build.
+type buildProgress struct {
+	output    io.Writer
+	verbosity Verbosity
+	sections  []string
+	now       func() time.Time
+
+	line        []byte
+	section     string
+	started     time.Time
+	steps       int
+	cached      int
+	step        int
+	instruction string
+	stepOutput  []string
+}
+
+func newBuildProgress(output io.Writer, verbosity Verbosity, dockerfile []byte, sections []string) *buildProgress {
+	return &buildProgress{
+		output:    output,
+		verbosity: verbosity,
+		sections:  dockerfileSections(dockerfile, sections),
+		now:       time.Now,
+	}
+}
+
+func (b *buildProgress) Write(data []byte) (int, error) {
+	b.line = append(b.line, data...)
+	for {
+		end := bytes.IndexByte(b.line, '\n')
+		if end < 0 {
+			break
+		}
+		b.parseLine(strings.TrimRight(string(b.line[:end]), "\r"))
+		b.line = b.line[end+1:]
+	}
+	return len(data), nil
+}
+
+// parseLine parses a line of the docker build output, in verbose mode it is shown as well.
+func (b *buildProgress) parseLine(line string) {
+	match := buildStep.FindStringSubmatch(line)
+	if match == nil {
+		b.stepOutput = append(b.stepOutput, line)
+		if strings.Contains(line, "Using cache") {
+			b.cached++
+		}
+		b.verbose(line)
+		return
+	}
+
+	b.step, _ = strconv.Atoi(match[1])
+	b.instruction = match[2]
+	b.stepOutput = nil
+
+	section := baseSection
+	if b.step > 0 && b.step <= len(b.sections) {
+		section = b.sections[b.step-1]
+	}
+	if b.steps == 0 || section != b.section {
+		b.finishSection()
+		b.section = section
+		b.started = b.now()
+		b.verbose("==> " + section)
+	}
+	b.steps++
+	b.verbose(line)
+}
+
+func (b *buildProgress) verbose(line string) {
+	if b.verbosity == VerbosityVerbose {
+		fmt.Fprintln(b.output, line)
+	}
+}
+
+// finishSection shows how long the current section took.
+func (b *buildProgress) finishSection() {
+	if b.steps == 0 {
+		return
+	}
+	if b.verbosity != VerbosityQuiet {
+		cached := ""
+		if b.cached > 0 {
+			cached = fmt.Sprintf(", %d cached", b.cached)
+		}
+		steps := "steps"
+		if b.steps == 1 {
+			steps = "step"
+		}
+		fmt.Fprintf(b.output, "  %s: %d %s%s, %s\n", b.section, b.steps, steps, cached, b.elapsed())
+	}
+	b.steps, b.cached = 0, 0
+}
+
+func (b *buildProgress) elapsed() time.Duration {
+	elapsed := b.now().Sub(b.started)
+	return elapsed - elapsed%(100*time.Millisecond)
+}
+
+// finish ends the progress of the build, the error of a failed build is extended by the
+// feature and the instruction which failed.
+func (b *buildProgress) finish(err error) error {
+	if len(b.line) > 0 {
+		b.parseLine(string(b.line))
+		b.line = nil
+	}
+	if err == nil {
+		b.finishSection()
+		return nil
+	}
+	if b.step == 0 {
+		return fmt.Errorf("Error: %s", err)
+	}
+
+	if b.verbosity == VerbosityNormal {
+		fmt.Fprintf(b.output, "  %s: failed after %s\n", b.section, b.elapsed())
+		for _, line := range b.stepOutput {
+			fmt.Fprintf(b.output, "    %s\n", line)
+		}
+	}
+	return fmt.Errorf("Error: %s failed at step %d (%s): %s", b.section, b.step, b.instruction, err)
+}
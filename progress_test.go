@@ -0,0 +1,119 @@
+package pazuzu
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var progressDockerfile = `# Generated by pazuzu
+FROM ubuntu
+LABEL maintainer="team"
+# python
+RUN apt-get update && \
+    apt-get install python --yes
+ENV PYTHONPATH /opt
+# cleanup
+# curl
+RUN apt-get install curl --yes
+# default command
+CMD /bin/bash
+`
+
+var progressSections = []string{"python", "curl", commandSection}
+
+func TestDockerfileSections(t *testing.T) {
+	tests := []struct {
+		dockerfile string
+		names      []string
+		want       []string
+	}{
+		{progressDockerfile, progressSections, []string{baseSection, baseSection, "python", "python", "curl", commandSection}},
+		{"FROM ubuntu\n# command\nRUN make\n# default command\nCMD /bin/bash\n", []string{"command", commandSection},
+			[]string{baseSection, "command", commandSection}},
+		{"FROM ubuntu\n# node\n# python\nRUN install\n", []string{"python", commandSection},
+			[]string{baseSection, "python"}},
+	}
+	for _, tt := range tests {
+		if got := dockerfileSections([]byte(tt.dockerfile), tt.names); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("dockerfileSections(%v) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+}
+
+var progressOutput = `Step 1/6 : FROM ubuntu
+ ---> 0ef2e08ed3fa
+Step 2/6 : LABEL maintainer="team"
+ ---> Using cache
+ ---> 3b4f2a8c9d1e
+Step 3/6 : RUN apt-get update &&     apt-get install python --yes
+ ---> Running in 9a8b7c6d5e4f
+Reading package lists...
+Step 4/6 : ENV PYTHONPATH /opt
+Step 5/6 : RUN apt-get install curl --yes
+E: Unable to locate package curl
+`
+
+func TestBuildProgress(t *testing.T) {
+	tests := []struct {
+		verbosity Verbosity
+		err       error
+		want      string
+	}{
+		{VerbosityNormal, nil, `  base image: 2 steps, 1 cached, 500ms
+  python: 2 steps, 500ms
+  curl: 1 step, 500ms
+`},
+		{VerbosityNormal, errors.New("returned a non-zero code: 100"), `  base image: 2 steps, 1 cached, 500ms
+  python: 2 steps, 500ms
+  curl: failed after 500ms
+    E: Unable to locate package curl
+`},
+		{VerbosityQuiet, errors.New("returned a non-zero code: 100"), ""},
+		{VerbosityVerbose, nil, `==> base image
+Step 1/6 : FROM ubuntu
+ ---> 0ef2e08ed3fa
+Step 2/6 : LABEL maintainer="team"
+ ---> Using cache
+ ---> 3b4f2a8c9d1e
+  base image: 2 steps, 1 cached, 500ms
+==> python
+Step 3/6 : RUN apt-get update &&     apt-get install python --yes
+ ---> Running in 9a8b7c6d5e4f
+Reading package lists...
+Step 4/6 : ENV PYTHONPATH /opt
+  python: 2 steps, 500ms
+==> curl
+Step 5/6 : RUN apt-get install curl --yes
+E: Unable to locate package curl
+  curl: 1 step, 500ms
+`},
+	}
+	for _, tt := range tests {
+		var output bytes.Buffer
+		progress := newBuildProgress(&output, tt.verbosity, []byte(progressDockerfile), progressSections)
+		var clock time.Time
+		progress.now = func() time.Time {
+			clock = clock.Add(500 * time.Millisecond)
+			return clock
+		}
+		// docker streams the output in arbitrary chunks
+		for _, chunk := range strings.SplitAfter(progressOutput, "l") {
+			progress.Write([]byte(chunk))
+		}
+		err := progress.finish(tt.err)
+
+		if tt.err == nil && err != nil {
+			t.Errorf("verbosity %d: should not fail: %s", tt.verbosity, err)
+		}
+		if tt.err != nil && (err == nil || !strings.Contains(err.Error(), "curl failed at step 5 (RUN apt-get install curl --yes)")) {
+			t.Errorf("verbosity %d: error should name the failed feature: %v", tt.verbosity, err)
+		}
+		if got := output.String(); got != tt.want {
+			t.Errorf("verbosity %d: output = %q, want %q", tt.verbosity, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package pazuzu
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the test results as JUnit XML with a test suite per feature.
+func WriteJUnitReport(writer io.Writer, results []FeatureTestResults) error {
+	report := junitTestSuites{}
+	for _, result := range results {
+		suite := junitTestSuite{Name: result.Name()}
+		for _, test := range result.Tests {
+			testCase := junitTestCase{Name: test.Name, Classname: result.Name(), File: result.File}
+			switch test.Status {
+			case TestFailed:
+				testCase.Failure = &junitMessage{Message: "Test failed", Text: test.Diagnostics}
+				suite.Failures++
+			case TestSkipped:
+				testCase.Skipped = &junitMessage{Message: test.Diagnostics}
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+			suite.Tests++
+		}
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+// WriteJSONReport writes the test results as a JSON list of features.
+func WriteJSONReport(writer io.Writer, results []FeatureTestResults) error {
+	if results == nil {
+		results = []FeatureTestResults{}
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
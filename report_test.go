@@ -0,0 +1,84 @@
+package pazuzu
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteJUnitReport(t *testing.T) {
+	results := []FeatureTestResults{
+		{Feature: "python", File: "tests/python/test.bats", Tests: []TestResult{
+			{Number: 1, Name: "python is installed", Status: TestPassed},
+			{Number: 2, Name: "pip is installed", Status: TestFailed, Diagnostics: "pip: not found"},
+		}},
+		{Feature: "node", File: "tests/node/test.sh", Tests: []TestResult{
+			{Number: 1, Name: "node", Status: TestSkipped, Diagnostics: "no network"},
+		}},
+		{Feature: "curl", Tests: []TestResult{}},
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteJUnitReport(&buffer, results); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1" skipped="1">
+  <testsuite name="python" tests="2" failures="1" skipped="0">
+    <testcase name="python is installed" classname="python" file="tests/python/test.bats"></testcase>
+    <testcase name="pip is installed" classname="python" file="tests/python/test.bats">
+      <failure message="Test failed">pip: not found</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="node" tests="1" failures="0" skipped="1">
+    <testcase name="node" classname="node" file="tests/node/test.sh">
+      <skipped message="no network"></skipped>
+    </testcase>
+  </testsuite>
+  <testsuite name="curl" tests="0" failures="0" skipped="0"></testsuite>
+</testsuites>
+`
+	if buffer.String() != want {
+		t.Errorf("WriteJUnitReport() =\n%s\nwant\n%s", buffer.String(), want)
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteJSONReport(&buffer, nil); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if buffer.String() != "[]\n" {
+		t.Errorf("WriteJSONReport(nil) = %q, want %q", buffer.String(), "[]\n")
+	}
+
+	buffer.Reset()
+	results := []FeatureTestResults{
+		{Feature: "python", File: "tests/python/test.bats", Tests: []TestResult{{Number: 1, Name: "python is installed", Status: TestPassed}}},
+		{Feature: "curl", Tests: []TestResult{}},
+	}
+	if err := WriteJSONReport(&buffer, results); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	want := `[
+  {
+    "feature": "python",
+    "file": "tests/python/test.bats",
+    "tests": [
+      {
+        "number": 1,
+        "name": "python is installed",
+        "status": "passed"
+      }
+    ]
+  },
+  {
+    "feature": "curl",
+    "tests": []
+  }
+]
+`
+	if buffer.String() != want {
+		t.Errorf("WriteJSONReport() =\n%s\nwant\n%s", buffer.String(), want)
+	}
+}
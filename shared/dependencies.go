@@ -0,0 +1,175 @@
+package shared
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConflictError describes two resolved features which declare a conflict with each other.
+// Chains contain the dependency path from a requested feature to the conflicting one.
+type ConflictError struct {
+	Feature            string
+	ConflictsWith      string
+	FeatureChain       []string
+	ConflictsWithChain []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Feature %s conflicts with %s: %s, %s",
+		e.Feature, e.ConflictsWith,
+		describeChain(e.Feature, e.FeatureChain),
+		describeChain(e.ConflictsWith, e.ConflictsWithChain))
+}
+
+func describeChain(name string, chain []string) string {
+	if len(chain) <= 1 {
+		return fmt.Sprintf("%s is requested directly", name)
+	}
+	return fmt.Sprintf("%s is pulled in by %s", name, strings.Join(chain, " -> "))
+}
+
+// LookupDependency returns the name of the resolved feature which satisfies the given
+// dependency: either the feature with the same name or a feature providing it.
+func LookupDependency(features map[string]Feature, dependency string) (string, bool) {
+	if _, ok := features[dependency]; ok {
+		return dependency, true
+	}
+
+	for _, name := range sortedNames(features) {
+		if features[name].Meta.Satisfies(dependency) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// DependencyChains returns for every resolved feature the shortest chain of dependencies
+// leading from one of the requested features to it. The chain starts with the requested
+// feature and ends with the feature itself.
+func DependencyChains(requested []string, features map[string]Feature) map[string][]string {
+	chains := map[string][]string{}
+	var queue []string
+
+	for _, name := range requested {
+		if _, ok := features[name]; !ok {
+			continue
+		}
+		if _, seen := chains[name]; seen {
+			continue
+		}
+		chains[name] = []string{name}
+		queue = append(queue, name)
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		for _, virtual := range features[name].Meta.Dependencies {
+			dependency, ok := LookupDependency(features, virtual)
+			if !ok {
+				continue
+			}
+			if _, seen := chains[dependency]; seen {
+				continue
+			}
+			chain := make([]string, len(chains[name]), len(chains[name])+1)
+			copy(chain, chains[name])
+			chains[dependency] = append(chain, dependency)
+			queue = append(queue, dependency)
+		}
+	}
+
+	return chains
+}
+
+// CheckConflicts verifies that none of the resolved features conflicts with another one.
+// Both direct and transitive features are taken into account, a conflict declared on
+// either side is enough. A conflict with a virtual feature applies to all its providers
+// except the declaring feature itself. The first found conflict is returned as *ConflictError.
+func CheckConflicts(requested []string, features map[string]Feature) error {
+	for _, name := range sortedNames(features) {
+		for _, conflict := range features[name].Meta.Conflicts {
+			other, ok := LookupDependency(features, conflict)
+			if !ok || other == name {
+				continue
+			}
+			chains := DependencyChains(requested, features)
+			return &ConflictError{
+				Feature:            name,
+				ConflictsWith:      other,
+				FeatureChain:       chains[name],
+				ConflictsWithChain: chains[other],
+			}
+		}
+	}
+
+	return nil
+}
+
+func sortedNames(features map[string]Feature) []string {
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolvedDependencies returns names of the resolved features the given feature depends on,
+// including its optional dependencies which are part of the resolution.
+func ResolvedDependencies(features map[string]Feature, name string) []string {
+	meta := features[name].Meta
+
+	var result []string
+	for _, dependency := range append(append([]string{}, meta.Dependencies...), meta.OptionalDependencies...) {
+		resolved, ok := LookupDependency(features, dependency)
+		if !ok {
+			continue
+		}
+		seen := false
+		for _, r := range result {
+			if r == resolved {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			result = append(result, resolved)
+		}
+	}
+	return result
+}
+
+// DependencyPaths returns every chain of dependencies leading from one of the requested
+// features to the target feature. Chains are ordered by the requested features.
+func DependencyPaths(requested []string, features map[string]Feature, target string) [][]string {
+	var paths [][]string
+	var path []string
+
+	var walk func(name string)
+	walk = func(name string) {
+		for _, n := range path {
+			if n == name {
+				return
+			}
+		}
+		path = append(path, name)
+		if name == target {
+			paths = append(paths, append([]string{}, path...))
+		} else {
+			for _, dependency := range ResolvedDependencies(features, name) {
+				walk(dependency)
+			}
+		}
+		path = path[:len(path)-1]
+	}
+
+	for _, name := range requested {
+		if _, ok := features[name]; ok {
+			walk(name)
+		}
+	}
+	return paths
+}
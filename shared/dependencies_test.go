@@ -0,0 +1,131 @@
+package shared
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testFeatures(metas ...FeatureMeta) map[string]Feature {
+	features := map[string]Feature{}
+	for _, meta := range metas {
+		features[meta.Name] = Feature{Meta: meta}
+	}
+	return features
+}
+
+func TestDependencyChains(t *testing.T) {
+	features := testFeatures(
+		FeatureMeta{Name: "app", Dependencies: []string{"python2", "curl"}},
+		FeatureMeta{Name: "python2", Dependencies: []string{"curl"}},
+		FeatureMeta{Name: "curl"},
+	)
+
+	chains := DependencyChains([]string{"app"}, features)
+
+	want := map[string][]string{
+		"app":     {"app"},
+		"python2": {"app", "python2"},
+		"curl":    {"app", "curl"},
+	}
+	if !reflect.DeepEqual(chains, want) {
+		t.Errorf("DependencyChains() = %v, want %v", chains, want)
+	}
+}
+
+func TestCheckConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		features  map[string]Feature
+		wantErr   *ConflictError
+	}{
+		{
+			"No conflicts",
+			[]string{"python3", "curl"},
+			testFeatures(
+				FeatureMeta{Name: "python3", Conflicts: []string{"python2"}},
+				FeatureMeta{Name: "curl"},
+			),
+			nil,
+		},
+		{
+			"Direct conflict",
+			[]string{"python2", "python3"},
+			testFeatures(
+				FeatureMeta{Name: "python2"},
+				FeatureMeta{Name: "python3", Conflicts: []string{"python2"}},
+			),
+			&ConflictError{"python3", "python2", []string{"python3"}, []string{"python2"}},
+		},
+		{
+			"Transitive conflict",
+			[]string{"app", "python3"},
+			testFeatures(
+				FeatureMeta{Name: "app", Dependencies: []string{"python2"}},
+				FeatureMeta{Name: "python2", Conflicts: []string{"python3"}},
+				FeatureMeta{Name: "python3"},
+			),
+			&ConflictError{"python2", "python3", []string{"app", "python2"}, []string{"python3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckConflicts(tt.requested, tt.features)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("CheckConflicts() error = %v, want nil", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("CheckConflicts() error = %#v, want %#v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConflictErrorMessage(t *testing.T) {
+	err := &ConflictError{"python2", "python3", []string{"app", "python2"}, []string{"python3"}}
+	message := err.Error()
+
+	for _, part := range []string{"python2 is pulled in by app -> python2", "python3 is requested directly"} {
+		if !strings.Contains(message, part) {
+			t.Errorf("Message %q should contain %q", message, part)
+		}
+	}
+}
+
+func TestLookupDependency(t *testing.T) {
+	features := testFeatures(
+		FeatureMeta{Name: "maven", Dependencies: []string{"jdk"}},
+		FeatureMeta{Name: "openjdk-8", Provides: []string{"jdk"}},
+	)
+
+	if name, ok := LookupDependency(features, "jdk"); !ok || name != "openjdk-8" {
+		t.Errorf("LookupDependency() = %v, %v, want openjdk-8", name, ok)
+	}
+	if _, ok := LookupDependency(features, "python"); ok {
+		t.Error("LookupDependency() should not find missing dependency")
+	}
+}
+
+func TestDependencyPaths(t *testing.T) {
+	features := testFeatures(
+		FeatureMeta{Name: "app", Dependencies: []string{"python2", "curl"}},
+		FeatureMeta{Name: "python2", Dependencies: []string{"curl"}},
+		FeatureMeta{Name: "node", OptionalDependencies: []string{"curl", "yarn"}},
+		FeatureMeta{Name: "curl"},
+	)
+
+	paths := DependencyPaths([]string{"app", "node"}, features, "curl")
+
+	want := [][]string{
+		{"app", "python2", "curl"},
+		{"app", "curl"},
+		{"node", "curl"},
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("DependencyPaths() = %v, want %v", paths, want)
+	}
+}
@@ -1,6 +1,8 @@
 package shared
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"github.com/zalando-incubator/pazuzu/swagger/models"
 	"time"
 )
@@ -13,6 +15,15 @@ type FeatureMeta struct {
 	Author       string
 	UpdatedAt    time.Time
 	Dependencies []string
+	Conflicts    []string
+	Provides     []string
+
+	// OptionalDependencies are installed before the feature only if the project requests them.
+	OptionalDependencies []string
+	// TestDependencies are required only by the TestSnippet of the feature.
+	TestDependencies []string
+	// TestRunner runs the TestSnippet of the feature, bats if empty.
+	TestRunner string
 }
 
 // Feature is a definition for a piece of work to be done. Contains meta information as well as
@@ -23,6 +34,29 @@ type Feature struct {
 	TestSnippet string
 }
 
+// Satisfies reports whether the feature can be used to satisfy a dependency on the given name,
+// either by its own name or by one of the virtual features it provides.
+func (m FeatureMeta) Satisfies(name string) bool {
+	if m.Name == name {
+		return true
+	}
+	for _, provided := range m.Provides {
+		if provided == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash identifies the content of the feature: its snippets are hashed, meta data is not.
+func (f Feature) Hash() string {
+	hash := sha256.New()
+	hash.Write([]byte(f.Snippet))
+	hash.Write([]byte{0})
+	hash.Write([]byte(f.TestSnippet))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 func NewFeature(feature *models.Feature) Feature {
 	var f Feature
 	f.Meta = NewMeta(feature.Meta)
@@ -41,12 +75,26 @@ func NewMeta(meta *models.FeatureMeta) FeatureMeta {
 	m.Name = meta.Name
 	m.Description = meta.Description
 	m.Author = meta.Author
-	m.UpdatedAt, _ = time.Parse(meta.UpdatedAt, "2006-01-02T15:04:05-0700")
+	m.UpdatedAt = parseUpdatedAt(meta.UpdatedAt)
 	m.Dependencies = meta.Dependencies
+	m.Conflicts = meta.Conflicts
+	m.Provides = meta.Provides
+	m.OptionalDependencies = meta.OptionalDependencies
+	m.TestDependencies = meta.TestDependencies
+	m.TestRunner = meta.TestRunner
 
 	return m
 }
 
+func parseUpdatedAt(value string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05-0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
 func NewMeta_str(name string, desc string, auth string, dependencies []string) FeatureMeta {
 	var m FeatureMeta
 	m.Name = name
@@ -0,0 +1,50 @@
+package shared
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zalando-incubator/pazuzu/swagger/models"
+)
+
+func TestNewFeature(t *testing.T) {
+	feature := NewFeature(&models.Feature{
+		Meta: &models.FeatureMeta{
+			Name:         "openjdk",
+			Description:  "OpenJDK 8",
+			Author:       "team",
+			UpdatedAt:    "2017-03-01T10:00:00Z",
+			Dependencies: []string{"curl"},
+			Conflicts:    []string{"oraclejdk"},
+			Provides:     []string{"jdk"},
+
+			OptionalDependencies: []string{"maven"},
+			TestDependencies:     []string{"bats"},
+			TestRunner:           "bats",
+		},
+		Snippet:     "RUN apt-get install openjdk-8-jdk",
+		TestSnippet: "java -version",
+	})
+
+	want := Feature{
+		Meta: FeatureMeta{
+			Name:         "openjdk",
+			Description:  "OpenJDK 8",
+			Author:       "team",
+			UpdatedAt:    time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC),
+			Dependencies: []string{"curl"},
+			Conflicts:    []string{"oraclejdk"},
+			Provides:     []string{"jdk"},
+
+			OptionalDependencies: []string{"maven"},
+			TestDependencies:     []string{"bats"},
+			TestRunner:           "bats",
+		},
+		Snippet:     "RUN apt-get install openjdk-8-jdk",
+		TestSnippet: "java -version",
+	}
+	if !reflect.DeepEqual(feature, want) {
+		t.Errorf("NewFeature() = %+v, want %+v", feature, want)
+	}
+}
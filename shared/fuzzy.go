@@ -0,0 +1,162 @@
+package shared
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FuzzyThreshold is the minimal FuzzyScore of a name to be considered similar to a query.
+const FuzzyThreshold = 0.6
+
+// FuzzyScore rates how similar the name is to the query, from 0 (nothing in common)
+// to 1 (equal). It combines edit distance of the whole names, similarity of their
+// tokens (e.g. `open`, `jdk` and `8` in `open-jdk-8`) and containment of one in another.
+func FuzzyScore(query string, name string) float64 {
+	query = strings.ToLower(query)
+	name = strings.ToLower(name)
+	if query == name {
+		return 1
+	}
+
+	return maxScore(wordScore(query, name), tokenScore(tokenize(query), tokenize(name)))
+}
+
+// FuzzyFind returns the names similar to the query, the most similar first.
+func FuzzyFind(query string, names []string) []string {
+	var matches []fuzzyMatch
+	for _, name := range names {
+		if score := FuzzyScore(query, name); score >= FuzzyThreshold {
+			matches = append(matches, fuzzyMatch{name, score})
+		}
+	}
+	sort.Stable(fuzzyMatches(matches))
+
+	result := make([]string, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, match.name)
+	}
+	return result
+}
+
+type fuzzyMatch struct {
+	name  string
+	score float64
+}
+
+type fuzzyMatches []fuzzyMatch
+
+func (m fuzzyMatches) Len() int      { return len(m) }
+func (m fuzzyMatches) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m fuzzyMatches) Less(i, j int) bool {
+	if m[i].score != m[j].score {
+		return m[i].score > m[j].score
+	}
+	return m[i].name < m[j].name
+}
+
+// wordScore rates similarity of two words by their edit distance and containment of one in another.
+func wordScore(a string, b string) float64 {
+	score := editScore(a, b)
+
+	shorter, longer := []rune(a), []rune(b)
+	if len(shorter) > len(longer) {
+		shorter, longer = longer, shorter
+	}
+	if len(shorter) > 0 && strings.Contains(string(longer), string(shorter)) {
+		score = maxScore(score, 0.5+0.5*float64(len(shorter))/float64(len(longer)))
+	}
+	return score
+}
+
+func editScore(a string, b string) float64 {
+	longest := len([]rune(a))
+	if l := len([]rune(b)); l > longest {
+		longest = l
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// tokenScore is the average similarity of every query token to its closest name token.
+func tokenScore(queryTokens []string, nameTokens []string) float64 {
+	if len(queryTokens) == 0 || len(nameTokens) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, q := range queryTokens {
+		var best float64
+		for _, n := range nameTokens {
+			best = maxScore(best, wordScore(q, n))
+		}
+		total += best
+	}
+	return total / float64(len(queryTokens))
+}
+
+// tokenize splits the name on separators and on boundaries between letters and digits.
+func tokenize(name string) []string {
+	var tokens []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			tokens = append(tokens, string(current))
+			current = nil
+		}
+	}
+
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case len(current) > 0 && unicode.IsDigit(r) != unicode.IsDigit(current[len(current)-1]):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxScore(a float64, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
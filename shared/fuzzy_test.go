@@ -0,0 +1,67 @@
+package shared
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"node", "node", 0},
+		{"node", "nodejs", 2},
+		{"pyhton", "python", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestWordScore(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"node", "nodejs", 0.5 + 0.5*4/6},
+		{"é", "éa", 0.75},
+		{"ab", "é", 0},
+	}
+	for _, tt := range tests {
+		if got := wordScore(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("wordScore(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	want := []string{"open", "jdk", "8"}
+	if got := tokenize("open-jdk8"); !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize() = %v, want %v", got, want)
+	}
+}
+
+func TestFuzzyFind(t *testing.T) {
+	names := []string{"node", "python", "python3", "openjdk-8", "golang", "java"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"nodejs", []string{"node"}},
+		{"pyhton", []string{"python", "python3"}},
+		{"jdk-open", []string{"openjdk-8"}},
+		{"rust", []string{}},
+	}
+	for _, tt := range tests {
+		if got := FuzzyFind(tt.query, names); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FuzzyFind(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package shared
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SortByName      = "name"
+	SortByUpdatedAt = "updated"
+)
+
+// SearchParams describes a feature search. Empty fields do not restrict the search.
+type SearchParams struct {
+	Name         *regexp.Regexp // matched against the feature name
+	Author       string         // case-insensitive substring of the author
+	Description  string         // case-insensitive substring of the description
+	DependsOn    string         // direct (required or optional) dependency
+	UpdatedSince time.Time      // features updated at or after the given time
+	SortBy       string         // SortByName or SortByUpdatedAt (most recent first)
+	Limit        int            // maximal number of results, 0 is unlimited
+}
+
+// Matches reports whether the feature matches all the filters.
+func (params SearchParams) Matches(meta FeatureMeta) bool {
+	if params.Name != nil && !params.Name.MatchString(meta.Name) {
+		return false
+	}
+	if !containsFold(meta.Author, params.Author) || !containsFold(meta.Description, params.Description) {
+		return false
+	}
+	if params.DependsOn != "" && !dependsOn(meta, params.DependsOn) {
+		return false
+	}
+	if !params.UpdatedSince.IsZero() && meta.UpdatedAt.Before(params.UpdatedSince) {
+		return false
+	}
+	return true
+}
+
+// Apply filters, sorts and limits the given features according to the params.
+// It is used by storages which can't do (some of) it server-side.
+func (params SearchParams) Apply(metas []FeatureMeta) []FeatureMeta {
+	result := []FeatureMeta{}
+	for _, meta := range metas {
+		if params.Matches(meta) {
+			result = append(result, meta)
+		}
+	}
+
+	switch params.SortBy {
+	case SortByName:
+		sort.Stable(metaSorter{result, func(a, b FeatureMeta) bool { return a.Name < b.Name }})
+	case SortByUpdatedAt:
+		sort.Stable(metaSorter{result, func(a, b FeatureMeta) bool { return a.UpdatedAt.After(b.UpdatedAt) }})
+	}
+
+	if params.Limit > 0 && len(result) > params.Limit {
+		result = result[:params.Limit]
+	}
+	return result
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func dependsOn(meta FeatureMeta, name string) bool {
+	for _, dependency := range append(append([]string{}, meta.Dependencies...), meta.OptionalDependencies...) {
+		if dependency == name {
+			return true
+		}
+	}
+	return false
+}
+
+type metaSorter struct {
+	metas []FeatureMeta
+	less  func(a, b FeatureMeta) bool
+}
+
+func (s metaSorter) Len() int           { return len(s.metas) }
+func (s metaSorter) Swap(i, j int)      { s.metas[i], s.metas[j] = s.metas[j], s.metas[i] }
+func (s metaSorter) Less(i, j int) bool { return s.less(s.metas[i], s.metas[j]) }
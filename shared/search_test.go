@@ -0,0 +1,45 @@
+package shared
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSearchParamsApply(t *testing.T) {
+	metas := []FeatureMeta{
+		{Name: "maven", Author: "Jane", Description: "Build tool", Dependencies: []string{"java"},
+			UpdatedAt: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "java", Author: "John", Description: "Java runtime",
+			UpdatedAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "gradle", Author: "jane", Description: "Build tool", OptionalDependencies: []string{"java"},
+			UpdatedAt: time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name   string
+		params SearchParams
+		want   []string
+	}{
+		{"No filters", SearchParams{}, []string{"maven", "java", "gradle"}},
+		{"Name", SearchParams{Name: regexp.MustCompile("^ma")}, []string{"maven"}},
+		{"Author", SearchParams{Author: "JANE", SortBy: SortByName}, []string{"gradle", "maven"}},
+		{"Description", SearchParams{Description: "runtime"}, []string{"java"}},
+		{"Depends on", SearchParams{DependsOn: "java"}, []string{"maven", "gradle"}},
+		{"Updated since", SearchParams{UpdatedSince: time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)}, []string{"maven", "gradle"}},
+		{"Sort by updated", SearchParams{SortBy: SortByUpdatedAt}, []string{"maven", "gradle", "java"}},
+		{"Limit", SearchParams{SortBy: SortByName, Limit: 2}, []string{"gradle", "java"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{}
+			for _, meta := range tt.params.Apply(metas) {
+				names = append(names, meta.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("Apply() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
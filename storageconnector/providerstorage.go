@@ -0,0 +1,245 @@
+package storageconnector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+// providerStorage wraps a StorageReader and resolves dependencies client-side, so that
+// dependencies on virtual features (like `jdk`) can be satisfied by one of their providers.
+type providerStorage struct {
+	StorageReader
+	Defaults map[string]string         // virtual feature -> default provider
+	Pinned   map[string]shared.Feature // feature name -> version used instead of the stored one
+}
+
+// NewProviderStorage creates a StorageReader which satisfies dependencies on virtual features
+// by a provider requested alongside, by a configured default provider or fails listing the
+// candidate providers.
+// defaults:	default provider name for every virtual feature
+func NewProviderStorage(reader StorageReader, defaults map[string]string) StorageReader {
+	return NewPinnedStorage(reader, defaults, nil)
+}
+
+// NewPinnedStorage creates a StorageReader like NewProviderStorage, which in addition
+// returns the pinned versions of features instead of the ones in the wrapped storage.
+// pinned:	feature versions to use, e.g. the ones recorded by the last generation
+func NewPinnedStorage(reader StorageReader, defaults map[string]string, pinned map[string]shared.Feature) StorageReader {
+	if defaults == nil {
+		defaults = map[string]string{}
+	}
+	if pinned == nil {
+		pinned = map[string]shared.Feature{}
+	}
+	return &providerStorage{StorageReader: reader, Defaults: defaults, Pinned: pinned}
+}
+
+// GetFeature returns the pinned version of the feature if there is one.
+func (store *providerStorage) GetFeature(name string) (shared.Feature, error) {
+	if feature, ok := store.Pinned[name]; ok {
+		return feature, nil
+	}
+	return store.StorageReader.GetFeature(name)
+}
+
+// GetMeta returns meta of the pinned version of the feature if there is one.
+func (store *providerStorage) GetMeta(name string) (shared.FeatureMeta, error) {
+	if feature, ok := store.Pinned[name]; ok {
+		return feature.Meta, nil
+	}
+	return store.StorageReader.GetMeta(name)
+}
+
+// Resolve a list of features and their dependencies. Features requested directly take
+// precedence when looking for a provider of a virtual feature, then the configured
+// default providers are used. Optional dependencies are only resolved if requested
+// directly, test dependencies are not resolved at all.
+// Without pinned features, the wrapped storage resolves the features as long as none of
+// them is virtual or has optional dependencies.
+// names:	an array of feature names
+func (store *providerStorage) Resolve(names ...string) ([]string, map[string]shared.Feature, error) {
+	if len(store.Pinned) == 0 {
+		order, features, err := store.StorageReader.Resolve(names...)
+		if err == nil && isPlainResolution(names, order, features) {
+			return order, features, nil
+		}
+	}
+
+	r := &resolution{
+		store:     store,
+		fetched:   map[string]shared.Feature{},
+		failed:    map[string]error{},
+		provided:  map[string]string{},
+		requested: map[string]bool{},
+		visiting:  map[string]bool{},
+		features:  map[string]shared.Feature{},
+	}
+
+	for _, name := range names {
+		r.requested[name] = true
+		if feature, err := r.fetch(name); err == nil {
+			r.provide(feature)
+			r.requested[feature.Meta.Name] = true
+			for _, virtual := range feature.Meta.Provides {
+				r.requested[virtual] = true
+			}
+		}
+	}
+
+	for _, name := range names {
+		if err := r.visit(name); err != nil {
+			return []string{}, map[string]shared.Feature{}, err
+		}
+	}
+	return r.order, r.features, nil
+}
+
+// isPlainResolution reports whether the features resolved by the wrapped storage can be used
+// as they are: all of them are in order after their dependencies, none of them provides a
+// virtual feature or has optional dependencies.
+func isPlainResolution(names []string, order []string, features map[string]shared.Feature) bool {
+	if len(order) != len(features) {
+		return false
+	}
+	installed := map[string]bool{}
+	for _, name := range order {
+		feature, ok := features[name]
+		if !ok || len(feature.Meta.Provides) > 0 || len(feature.Meta.OptionalDependencies) > 0 {
+			return false
+		}
+		for _, dependency := range feature.Meta.Dependencies {
+			if !installed[dependency] {
+				return false
+			}
+		}
+		installed[name] = true
+	}
+	for _, name := range names {
+		if !installed[name] {
+			return false
+		}
+	}
+	return true
+}
+
+type resolution struct {
+	store     *providerStorage
+	fetched   map[string]shared.Feature
+	failed    map[string]error
+	provided  map[string]string
+	requested map[string]bool
+	visiting  map[string]bool
+	features  map[string]shared.Feature
+	order     []string
+	metas     []shared.FeatureMeta // all features of the storage, searched at most once
+	searched  bool
+}
+
+func (r *resolution) fetch(name string) (shared.Feature, error) {
+	if feature, ok := r.fetched[name]; ok {
+		return feature, nil
+	}
+	if err, ok := r.failed[name]; ok {
+		return shared.Feature{}, err
+	}
+	feature, err := r.store.GetFeature(name)
+	if err != nil {
+		r.failed[name] = err
+		return shared.Feature{}, err
+	}
+	r.fetched[name] = feature
+	return feature, nil
+}
+
+func (r *resolution) provide(feature shared.Feature) {
+	for _, virtual := range feature.Meta.Provides {
+		if _, ok := r.provided[virtual]; !ok {
+			r.provided[virtual] = feature.Meta.Name
+		}
+	}
+}
+
+func (r *resolution) lookup(name string) (shared.Feature, error) {
+	if provider, ok := r.provided[name]; ok {
+		return r.fetch(provider)
+	}
+
+	feature, err := r.fetch(name)
+	if err == nil {
+		return feature, nil
+	}
+
+	if provider, ok := r.store.Defaults[name]; ok {
+		return r.fetch(provider)
+	}
+
+	candidates, errSearch := r.providersOf(name)
+	if errSearch != nil || len(candidates) == 0 {
+		return shared.Feature{}, err
+	}
+	return shared.Feature{}, fmt.Errorf(
+		"Feature %s is virtual, add one of its providers to the project or configure a default one: %s",
+		name, strings.Join(candidates, ", "))
+}
+
+func (r *resolution) visit(name string) error {
+	feature, err := r.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	featureName := feature.Meta.Name
+	if _, ok := r.features[featureName]; ok {
+		return nil
+	}
+	if r.visiting[featureName] {
+		return fmt.Errorf("Circular dependency on feature %s", featureName)
+	}
+
+	r.visiting[featureName] = true
+	for _, dependency := range feature.Meta.Dependencies {
+		if err := r.visit(dependency); err != nil {
+			return err
+		}
+	}
+	for _, dependency := range feature.Meta.OptionalDependencies {
+		if !r.requested[dependency] {
+			continue
+		}
+		if err := r.visit(dependency); err != nil {
+			return err
+		}
+	}
+	delete(r.visiting, featureName)
+
+	r.features[featureName] = feature
+	r.order = append(r.order, featureName)
+	r.provide(feature)
+	return nil
+}
+
+// providersOf returns names of all features in the storage providing the given virtual feature.
+func (r *resolution) providersOf(name string) ([]string, error) {
+	if !r.searched {
+		metas, err := r.store.StorageReader.SearchMeta(anyFeature)
+		if err != nil {
+			return nil, err
+		}
+		r.metas, r.searched = metas, true
+	}
+
+	var providers []string
+	for _, meta := range r.metas {
+		if meta.Name != name && meta.Satisfies(name) {
+			providers = append(providers, meta.Name)
+		}
+	}
+	return providers, nil
+}
+
+// ReverseDependencies delegates to the wrapped storage.
+func (store *providerStorage) ReverseDependencies(meta shared.FeatureMeta) ([]shared.FeatureMeta, error) {
+	return ReverseDependencies(store.StorageReader, meta)
+}
@@ -0,0 +1,177 @@
+package storageconnector
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu/mock"
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+func newTestProviderStorage(defaults map[string]string) StorageReader {
+	return NewProviderStorage(mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "maven", Dependencies: []string{"jdk"}}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "openjdk-8", Provides: []string{"jdk"}}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "oracle-jdk-8", Provides: []string{"jdk"}}},
+	), defaults)
+}
+
+func TestProviderStorageResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults map[string]string
+		names    []string
+		want     []string
+		wantErr  string
+	}{
+		{"Provider from the project", nil, []string{"maven", "oracle-jdk-8"}, []string{"oracle-jdk-8", "maven"}, ""},
+		{"Default provider", map[string]string{"jdk": "openjdk-8"}, []string{"maven"}, []string{"openjdk-8", "maven"}, ""},
+		{"Project wins over default", map[string]string{"jdk": "openjdk-8"}, []string{"oracle-jdk-8", "maven"}, []string{"oracle-jdk-8", "maven"}, ""},
+		{"No provider chosen", nil, []string{"maven"}, nil, "openjdk-8, oracle-jdk-8"},
+		{"Unknown feature", nil, []string{"node"}, nil, "Feature node not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, features, err := newTestProviderStorage(tt.defaults).Resolve(tt.names...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() should not fail: %s", err)
+			}
+			if !reflect.DeepEqual(order, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", order, tt.want)
+			}
+			if len(features) != len(tt.want) {
+				t.Errorf("Resolve() returned %d features, want %d", len(features), len(tt.want))
+			}
+		})
+	}
+}
+
+// countingStorage records the calls made to the wrapped storage.
+type countingStorage struct {
+	*mock.FeatureStorage
+	calls []string
+}
+
+func (s *countingStorage) GetFeature(name string) (shared.Feature, error) {
+	s.calls = append(s.calls, "GetFeature "+name)
+	return s.FeatureStorage.GetFeature(name)
+}
+
+func (s *countingStorage) SearchMeta(name *regexp.Regexp) ([]shared.FeatureMeta, error) {
+	s.calls = append(s.calls, "SearchMeta")
+	return s.FeatureStorage.SearchMeta(name)
+}
+
+func (s *countingStorage) Resolve(names ...string) ([]string, map[string]shared.Feature, error) {
+	s.calls = append(s.calls, "Resolve "+strings.Join(names, ","))
+	return s.FeatureStorage.Resolve(names...)
+}
+
+func TestProviderStorageCalls(t *testing.T) {
+	tests := []struct {
+		name      string
+		defaults  map[string]string
+		names     []string
+		wantCalls []string
+	}{
+		{"Plain features are resolved by the storage", nil, []string{"curl"}, []string{"Resolve curl"}},
+		{"Virtual feature with default", map[string]string{"jdk": "openjdk-8"}, []string{"maven", "jdk"}, []string{
+			"Resolve maven,jdk",
+			"GetFeature maven", "GetFeature jdk",
+			"GetFeature openjdk-8",
+		}},
+		{"Virtual dependency without provider", nil, []string{"maven", "ant"}, []string{
+			"Resolve maven,ant",
+			"GetFeature maven", "GetFeature ant",
+			"GetFeature jdk", "SearchMeta",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := &countingStorage{FeatureStorage: mock.NewFeatureStorage(
+				shared.Feature{Meta: shared.FeatureMeta{Name: "curl"}},
+				shared.Feature{Meta: shared.FeatureMeta{Name: "maven", Dependencies: []string{"jdk"}}},
+				shared.Feature{Meta: shared.FeatureMeta{Name: "ant", Dependencies: []string{"jdk"}}},
+				shared.Feature{Meta: shared.FeatureMeta{Name: "openjdk-8", Provides: []string{"jdk"}}},
+				shared.Feature{Meta: shared.FeatureMeta{Name: "oracle-jdk-8", Provides: []string{"jdk"}}},
+			)}
+
+			NewProviderStorage(wrapped, tt.defaults).Resolve(tt.names...)
+			if !reflect.DeepEqual(wrapped.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", wrapped.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestProviderStorageCircularDependency(t *testing.T) {
+	storage := NewProviderStorage(mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "a", Dependencies: []string{"b"}}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "b", Dependencies: []string{"a"}}},
+	), nil)
+
+	_, _, err := storage.Resolve("a")
+	if err == nil {
+		t.Error("Circular dependency should not be resolved")
+	}
+}
+
+func TestProviderStorageOptionalDependencies(t *testing.T) {
+	storage := NewProviderStorage(mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "app", OptionalDependencies: []string{"node"}}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node"}},
+	), nil)
+
+	order, _, err := storage.Resolve("app")
+	if err != nil || !reflect.DeepEqual(order, []string{"app"}) {
+		t.Errorf("Resolve() = %v, %v, optional dependency should be skipped", order, err)
+	}
+
+	order, _, err = storage.Resolve("app", "node")
+	if err != nil || !reflect.DeepEqual(order, []string{"node", "app"}) {
+		t.Errorf("Resolve() = %v, %v, optional dependency should be installed first", order, err)
+	}
+}
+
+func TestPinnedStorage(t *testing.T) {
+	storage := NewPinnedStorage(mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "app", Dependencies: []string{"node"}}, Snippet: "RUN app"},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node"}, Snippet: "RUN node 8"},
+	), nil, map[string]shared.Feature{
+		"node": {Meta: shared.FeatureMeta{Name: "node"}, Snippet: "RUN node 6"},
+	})
+
+	_, features, err := storage.Resolve("app")
+	if err != nil {
+		t.Fatalf("Resolve() should not fail: %s", err)
+	}
+	if features["node"].Snippet != "RUN node 6" || features["app"].Snippet != "RUN app" {
+		t.Errorf("Resolve() = %v, pinned version of node should be used", features)
+	}
+
+	feature, err := storage.GetFeature("node")
+	if err != nil || feature.Snippet != "RUN node 6" {
+		t.Errorf("GetFeature() = %v, %v, pinned version should be returned", feature, err)
+	}
+}
+
+func TestReverseDependencies(t *testing.T) {
+	storage := newTestProviderStorage(nil)
+
+	meta, _ := storage.GetMeta("openjdk-8")
+	metas, err := ReverseDependencies(storage, meta)
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	if len(metas) != 1 || metas[0].Name != "maven" {
+		t.Errorf("ReverseDependencies() = %v, want [maven]", metas)
+	}
+}
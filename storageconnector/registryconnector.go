@@ -88,6 +88,21 @@ func (store *registryStorage) SearchMeta(name *regexp.Regexp) ([]shared.FeatureM
 	return result, err
 }
 
+// Use the given params to return a list of FeatureMeta.
+// The name is filtered server-side, all the other params are applied client-side.
+func (store *registryStorage) Search(params shared.SearchParams) ([]shared.FeatureMeta, error) {
+	name := params.Name
+	if name == nil {
+		name = anyFeature
+	}
+
+	metas, err := store.SearchMeta(name)
+	if err != nil {
+		return nil, err
+	}
+	return params.Apply(metas), nil
+}
+
 // Return a feature metadata from the storage.
 // name:	a value, that must present in feature name
 func (store *registryStorage) GetMeta(name string) (shared.FeatureMeta, error) {
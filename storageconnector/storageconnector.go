@@ -6,11 +6,18 @@ import (
 	"github.com/zalando-incubator/pazuzu/shared"
 )
 
+// anyFeature matches every feature name.
+var anyFeature = regexp.MustCompile("")
+
 // StorageReader defines an interface to get Features from data sources
 type StorageReader interface {
 	// SearchMeta returns an arbitrary ordered list of FeatureMeta records using given expression
 	SearchMeta(name *regexp.Regexp) ([]shared.FeatureMeta, error)
 
+	// Search returns a list of FeatureMeta records matching all the given params, sorted and limited
+	// as requested. Filters which can't be applied by a storage itself are applied client-side.
+	Search(params shared.SearchParams) ([]shared.FeatureMeta, error)
+
 	// GetMeta returns a single FeatureMeta by given Name. Meta is a small piece of data,
 	// so it should be indexed by a storage and accessed rather quickly.
 	GetMeta(name string) (shared.FeatureMeta, error)
@@ -28,3 +35,35 @@ type StorageReader interface {
 	// If a feature can't be found or a dependency can't be resolved an error is returned.
 	Resolve(names ...string) ([]string, map[string]shared.Feature, error)
 }
+
+// ReverseDependencyReader is implemented by storages which are able to look up the features
+// depending on a given one by themselves.
+type ReverseDependencyReader interface {
+	// ReverseDependencies returns metas of all features which depend directly on the given feature
+	// or on one of the virtual features it provides.
+	ReverseDependencies(meta shared.FeatureMeta) ([]shared.FeatureMeta, error)
+}
+
+// ReverseDependencies returns metas of all features which depend directly on the given feature.
+// Storages not implementing ReverseDependencyReader are scanned using SearchMeta.
+func ReverseDependencies(reader StorageReader, meta shared.FeatureMeta) ([]shared.FeatureMeta, error) {
+	if reverseReader, ok := reader.(ReverseDependencyReader); ok {
+		return reverseReader.ReverseDependencies(meta)
+	}
+
+	metas, err := reader.SearchMeta(anyFeature)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []shared.FeatureMeta
+	for _, candidate := range metas {
+		for _, dependency := range candidate.Dependencies {
+			if meta.Satisfies(dependency) {
+				result = append(result, candidate)
+				break
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package pazuzu
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+	"github.com/zalando-incubator/pazuzu/storageconnector"
+)
+
+// MaxSuggestions is the maximal number of similar features suggested when a feature is not found.
+const MaxSuggestions = 3
+
+// CheckFeatures fails for the first feature which is neither in the storage nor provided by
+// one of its features, suggesting similar feature names.
+func CheckFeatures(storage storageconnector.StorageReader, names []string) error {
+	var metas []shared.FeatureMeta
+	for _, name := range names {
+		if _, err := storage.GetMeta(name); err == nil {
+			continue
+		}
+		if metas == nil {
+			var err error
+			if metas, err = storage.SearchMeta(regexp.MustCompile("")); err != nil {
+				return err
+			}
+		}
+		if isProvided(metas, name) {
+			continue
+		}
+		if suggestions := suggest(name, metas); len(suggestions) > 0 {
+			return fmt.Errorf("Feature %s not found, did you mean: %s?", name, strings.Join(suggestions, ", "))
+		}
+		return fmt.Errorf("Feature %s not found", name)
+	}
+	return nil
+}
+
+// suggest returns up to MaxSuggestions feature names closest to the given name.
+func suggest(name string, metas []shared.FeatureMeta) []string {
+	var names []string
+	for _, meta := range metas {
+		names = append(names, meta.Name)
+	}
+
+	suggestions := shared.FuzzyFind(name, names)
+	if len(suggestions) > MaxSuggestions {
+		suggestions = suggestions[:MaxSuggestions]
+	}
+	return suggestions
+}
+
+// isProvided reports whether the virtual feature is provided by one of the features.
+func isProvided(metas []shared.FeatureMeta, virtual string) bool {
+	for _, meta := range metas {
+		if meta.Satisfies(virtual) {
+			return true
+		}
+	}
+	return false
+}
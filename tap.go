@@ -0,0 +1,168 @@
+package pazuzu
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+// Status of a test.
+const (
+	TestPassed  = "passed"
+	TestFailed  = "failed"
+	TestSkipped = "skipped"
+)
+
+// FeatureTestResults are the results of the tests of a feature. Tests is empty if the feature has no tests.
+type FeatureTestResults struct {
+	Feature string       `json:"feature" yaml:"feature"`
+	File    string       `json:"file,omitempty" yaml:"file,omitempty"`
+	Tests   []TestResult `json:"tests" yaml:"tests"`
+}
+
+// Name of the results, the test file if the results do not belong to a single feature.
+func (r FeatureTestResults) Name() string {
+	if r.Feature == "" {
+		return r.File
+	}
+	return r.Feature
+}
+
+// TestResult is the result of a single test.
+type TestResult struct {
+	Number      int    `json:"number" yaml:"number"`
+	Name        string `json:"name" yaml:"name"`
+	Status      string `json:"status" yaml:"status"`
+	Diagnostics string `json:"diagnostics,omitempty" yaml:"diagnostics,omitempty"`
+}
+
+var (
+	tapPlan      = regexp.MustCompile(`^1\.\.(\d+)`)
+	tapResult    = regexp.MustCompile(`^(ok|not ok)\b\s*(\d*)\s*(?:-\s+)?(.*)$`)
+	tapDirective = regexp.MustCompile(`(?i)^(.*?)\s*#\s*(skip|todo)\b\s*(.*)$`)
+	batsSkip     = regexp.MustCompile(`(?i)^#\s*skip\b\s*(?:\(([^)]*)\)\s*)?(.*)$`)
+)
+
+// ParseTAP reads test results in TAP format. Lines which are not part of TAP are ignored.
+// Tests announced by the plan but not reported are considered failed.
+func ParseTAP(reader io.Reader) ([]TestResult, error) {
+	var results []TestResult
+	plan := -1
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if match := tapPlan.FindStringSubmatch(line); match != nil && plan < 0 {
+			plan, _ = strconv.Atoi(match[1])
+			continue
+		}
+
+		if match := tapResult.FindStringSubmatch(line); match != nil {
+			result := TestResult{Number: len(results) + 1, Name: match[3], Status: TestPassed}
+			if match[2] != "" {
+				result.Number, _ = strconv.Atoi(match[2])
+			}
+			if match[1] == "not ok" {
+				result.Status = TestFailed
+			}
+			// bats puts the directive in front of the name: ok 1 # skip (reason) name
+			if skip := batsSkip.FindStringSubmatch(result.Name); skip != nil {
+				result.Name, result.Status, result.Diagnostics = skip[2], TestSkipped, skip[1]
+			} else if directive := tapDirective.FindStringSubmatch(result.Name); directive != nil {
+				result.Name, result.Status, result.Diagnostics = directive[1], TestSkipped, directive[3]
+			}
+			results = append(results, result)
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") && len(results) > 0 {
+			last := &results[len(results)-1]
+			diagnostics := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			if last.Diagnostics != "" {
+				diagnostics = last.Diagnostics + "\n" + diagnostics
+			}
+			last.Diagnostics = diagnostics
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return results, err
+	}
+
+	for number := len(results) + 1; number <= plan; number++ {
+		results = append(results, TestResult{
+			Number:      number,
+			Name:        fmt.Sprintf("test %d", number),
+			Status:      TestFailed,
+			Diagnostics: "Test did not run",
+		})
+	}
+	return results, nil
+}
+
+// withUntestedFeatures orders the results like the features and adds empty results for
+// features without tests. Results of no single feature are kept at the end.
+func withUntestedFeatures(features []shared.Feature, results []FeatureTestResults) []FeatureTestResults {
+	byFeature := map[string]FeatureTestResults{}
+	for _, result := range results {
+		if result.Feature != "" {
+			byFeature[result.Feature] = result
+		}
+	}
+
+	var ordered []FeatureTestResults
+	for _, feature := range features {
+		result, ok := byFeature[feature.Meta.Name]
+		if !ok {
+			result = FeatureTestResults{Feature: feature.Meta.Name, Tests: []TestResult{}}
+		}
+		ordered = append(ordered, result)
+	}
+	for _, result := range results {
+		if result.Feature == "" {
+			ordered = append(ordered, result)
+		}
+	}
+	return ordered
+}
+
+// WriteTestSummary writes the number of passed, failed and skipped tests of every feature
+// together with the failed tests.
+func WriteTestSummary(writer io.Writer, results []FeatureTestResults) error {
+	total := map[string]int{}
+	tests := 0
+	for _, result := range results {
+		for _, test := range result.Tests {
+			total[test.Status]++
+			tests++
+		}
+	}
+	if _, err := fmt.Fprintf(writer, "\n%d tests, %d passed, %d failed, %d skipped\n",
+		tests, total[TestPassed], total[TestFailed], total[TestSkipped]); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if len(result.Tests) == 0 {
+			fmt.Fprintf(writer, "  %s: no tests\n", result.Name())
+			continue
+		}
+		count := map[string]int{}
+		for _, test := range result.Tests {
+			count[test.Status]++
+		}
+		fmt.Fprintf(writer, "  %s: %d passed, %d failed, %d skipped\n",
+			result.Name(), count[TestPassed], count[TestFailed], count[TestSkipped])
+		for _, test := range result.Tests {
+			if test.Status == TestFailed {
+				fmt.Fprintf(writer, "    not ok %d %s\n", test.Number, test.Name)
+			}
+		}
+	}
+	return nil
+}
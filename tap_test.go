@@ -0,0 +1,100 @@
+package pazuzu
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+func TestParseTAP(t *testing.T) {
+	tests := []struct {
+		name string
+		tap  string
+		want []TestResult
+	}{
+		{"bats", `1..4
+ok 1 python is installed
+not ok 2 python3 is installed
+# (in test file /pazuzu/test.bats, line 7)
+#   ` + "`[ -x /usr/bin/python3 ]'" + ` failed
+ok 3 # skip (no network) pip can install packages
+ok 4 # skip pip is configured
+`, []TestResult{
+			{Number: 1, Name: "python is installed", Status: TestPassed},
+			{Number: 2, Name: "python3 is installed", Status: TestFailed,
+				Diagnostics: "(in test file /pazuzu/test.bats, line 7)\n`[ -x /usr/bin/python3 ]' failed"},
+			{Number: 3, Name: "pip can install packages", Status: TestSkipped, Diagnostics: "no network"},
+			{Number: 4, Name: "pip is configured", Status: TestSkipped},
+		}},
+		{"directives and noise", `Starting tests
+1..3
+ok - first
+not ok 2 - second # TODO not implemented
+ok third
+`, []TestResult{
+			{Number: 1, Name: "first", Status: TestPassed},
+			{Number: 2, Name: "second", Status: TestSkipped, Diagnostics: "not implemented"},
+			{Number: 3, Name: "third", Status: TestPassed},
+		}},
+		{"missing tests", "1..2\nok 1 first\n", []TestResult{
+			{Number: 1, Name: "first", Status: TestPassed},
+			{Number: 2, Name: "test 2", Status: TestFailed, Diagnostics: "Test did not run"},
+		}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		got, err := ParseTAP(strings.NewReader(tt.tap))
+		if err != nil {
+			t.Fatalf("%s: should not fail: %s", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseTAP() =\n%#v\nwant\n%#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithUntestedFeatures(t *testing.T) {
+	features := []shared.Feature{{Meta: shared.FeatureMeta{Name: "python"}}, {Meta: shared.FeatureMeta{Name: "curl"}}}
+	results := []FeatureTestResults{
+		{File: "test.bats", Tests: []TestResult{}},
+		{Feature: "python", File: "tests/python/test.bats", Tests: []TestResult{{Number: 1, Name: "python", Status: TestPassed}}},
+	}
+
+	want := []FeatureTestResults{results[1], {Feature: "curl", Tests: []TestResult{}}, results[0]}
+	if got := withUntestedFeatures(features, results); !reflect.DeepEqual(got, want) {
+		t.Errorf("withUntestedFeatures() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteTestSummary(t *testing.T) {
+	results := []FeatureTestResults{
+		{Feature: "python", Tests: []TestResult{
+			{Number: 1, Name: "python is installed", Status: TestPassed},
+			{Number: 2, Name: "pip is installed", Status: TestFailed},
+		}},
+		{Feature: "node", Tests: []TestResult{{Number: 1, Name: "node", Status: TestSkipped}}},
+		{Feature: "curl", Tests: []TestResult{}},
+		{File: "test.bats", Tests: []TestResult{{Number: 1, Name: "test.bats", Status: TestFailed}}},
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteTestSummary(&buffer, results); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	want := `
+4 tests, 1 passed, 2 failed, 1 skipped
+  python: 1 passed, 1 failed, 0 skipped
+    not ok 2 pip is installed
+  node: 0 passed, 0 failed, 1 skipped
+  curl: no tests
+  test.bats: 0 passed, 1 failed, 0 skipped
+    not ok 1 test.bats
+`
+	if buffer.String() != want {
+		t.Errorf("WriteTestSummary() =\n%s\nwant\n%s", buffer.String(), want)
+	}
+}
@@ -0,0 +1,228 @@
+package pazuzu
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"strings"
+
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+// Test runners selectable by features, bats is used by default.
+const (
+	TestRunnerBats   = "bats"
+	TestRunnerShell  = "shell"
+	TestRunnerChecks = "checks"
+)
+
+// TestRunner turns test snippets of features into a test file and runs it in the test container.
+// All runners report the results in TAP format.
+type TestRunner interface {
+	// Filename of the generated test file
+	Filename() string
+
+	// WriteTestSpec writes the test file for the given features
+	WriteTestSpec(writer io.Writer, features []shared.Feature) error
+
+	// Command returns the shell command running the test file at the given path
+	Command(path string) string
+}
+
+// TestFile is a generated test file together with the runner executing it.
+type TestFile struct {
+	Filename string
+	Runner   string
+	Contents []byte
+	Feature  string // feature of the tests, empty if the file contains the tests of several features
+}
+
+// TestRunnerNames lists the names of all test runners, the default one first.
+var TestRunnerNames = []string{TestRunnerBats, TestRunnerShell, TestRunnerChecks}
+
+// NewTestRunner creates the test runner of the given name.
+// bats:	local bats distribution used by the bats runner, see Pazuzu.Bats
+func NewTestRunner(name string, bats string) (TestRunner, error) {
+	switch name {
+	case "", TestRunnerBats:
+		return batsRunner{bats}, nil
+	case TestRunnerShell:
+		return shellRunner{}, nil
+	case TestRunnerChecks:
+		return checksRunner{}, nil
+	}
+	return nil, fmt.Errorf("Unknown test runner: %s", name)
+}
+
+// TestRunnerName returns the name of the runner of the feature's tests.
+func TestRunnerName(feature shared.Feature) string {
+	if feature.Meta.TestRunner == "" {
+		return TestRunnerBats
+	}
+	return feature.Meta.TestRunner
+}
+
+// TestFilenames returns the names of the test files of all runners.
+func TestFilenames() []string {
+	var names []string
+	for _, name := range TestRunnerNames {
+		runner, _ := NewTestRunner(name, "")
+		names = append(names, runner.Filename())
+	}
+	return names
+}
+
+// batsRunner runs bats tests. It is the default runner.
+type batsRunner struct {
+	bats string
+}
+
+func (r batsRunner) Filename() string {
+	return TestSpecFilename
+}
+
+func (r batsRunner) WriteTestSpec(writer io.Writer, features []shared.Feature) error {
+	return WriteTestSpec(writer, features)
+}
+
+func (r batsRunner) Command(path string) string {
+	return batsCommand(r.bats, path)
+}
+
+// shellRunner runs the test snippet of every feature as a shell script, which fails on
+// the first failing command. Output of the scripts goes to stderr to keep the TAP output clean.
+type shellRunner struct{}
+
+func (r shellRunner) Filename() string {
+	return "test.sh"
+}
+
+func (r shellRunner) WriteTestSpec(writer io.Writer, features []shared.Feature) error {
+	var buffer bytes.Buffer
+	writeTapHeader(&buffer, len(features))
+	for i, feature := range features {
+		fmt.Fprintf(&buffer, "\n# %s\n(\nset -e\n%s\n) >&2\n", feature.Meta.Name, strings.TrimRight(feature.TestSnippet, "\n"))
+		fmt.Fprintf(&buffer, "report $? %d %s\n", i+1, shellQuote(feature.Meta.Name))
+	}
+	writeTapFooter(&buffer)
+
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+func (r shellRunner) Command(path string) string {
+	return "/bin/sh " + path
+}
+
+// Checks describes the test of a feature for the checks runner in YAML.
+type Checks struct {
+	Commands []CommandCheck `yaml:"commands"`
+	Files    []string       `yaml:"files"`
+}
+
+// CommandCheck runs a command and checks its exit code and (optionally) its output.
+type CommandCheck struct {
+	Run      string `yaml:"run"`
+	ExitCode int    `yaml:"exit_code"`
+	Stdout   string `yaml:"stdout"` // regular expression (grep -E) matching a line of the output
+}
+
+// checksRunner runs declarative checks of commands and files, which are turned into a shell script.
+type checksRunner struct{}
+
+func (r checksRunner) Filename() string {
+	return "test-checks.sh"
+}
+
+func (r checksRunner) WriteTestSpec(writer io.Writer, features []shared.Feature) error {
+	type check struct {
+		function  string
+		arguments string
+	}
+
+	var tests []check
+	for _, feature := range features {
+		checks, err := readChecks(feature)
+		if err != nil {
+			return err
+		}
+
+		for _, command := range checks.Commands {
+			description := fmt.Sprintf("%s: %s", feature.Meta.Name, command.Run)
+			tests = append(tests, check{"check_command", fmt.Sprintf("%s %s %d %s",
+				shellQuote(description), shellQuote(command.Run), command.ExitCode, shellQuote(command.Stdout))})
+		}
+		for _, file := range checks.Files {
+			description := fmt.Sprintf("%s: %s exists", feature.Meta.Name, file)
+			tests = append(tests, check{"check_file", fmt.Sprintf("%s %s", shellQuote(description), shellQuote(file))})
+		}
+	}
+
+	var buffer bytes.Buffer
+	writeTapHeader(&buffer, len(tests))
+	buffer.WriteString(checkFunctions)
+	for i, test := range tests {
+		fmt.Fprintf(&buffer, "%s %d %s\n", test.function, i+1, test.arguments)
+	}
+	writeTapFooter(&buffer)
+
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+func (r checksRunner) Command(path string) string {
+	return "/bin/sh " + path
+}
+
+func readChecks(feature shared.Feature) (Checks, error) {
+	var checks Checks
+	if err := yaml.Unmarshal([]byte(feature.TestSnippet), &checks); err != nil {
+		return checks, fmt.Errorf("Invalid checks of feature %s: %s", feature.Meta.Name, err)
+	}
+	return checks, nil
+}
+
+const checkFunctions = `
+check_command() {
+	output=$(/bin/sh -c "$3" 2>/dev/null)
+	code=$?
+	if [ "$code" -ne "$4" ]; then
+		report 1 "$1" "$2" "exit code $code, expected $4"
+	elif [ -n "$5" ] && ! printf '%s\n' "$output" | grep -Eq "$5"; then
+		report 1 "$1" "$2" "output does not match $5"
+	else
+		report 0 "$1" "$2"
+	fi
+}
+
+check_file() {
+	[ -e "$3" ]
+	report $? "$1" "$2"
+}
+
+`
+
+// writeTapHeader starts a shell script reporting the given number of tests in TAP format.
+func writeTapHeader(buffer *bytes.Buffer, tests int) {
+	fmt.Fprintf(buffer, "#!/bin/sh\necho 1..%d\nfailures=0\n", tests)
+	buffer.WriteString(`
+report() {
+	if [ "$1" -eq 0 ]; then
+		echo "ok $2 $3"
+	else
+		failures=$((failures + 1))
+		echo "not ok $2 $3"
+		[ -z "$4" ] || echo "# $4"
+	fi
+}
+`)
+}
+
+func writeTapFooter(buffer *bytes.Buffer) {
+	buffer.WriteString("\n[ \"$failures\" -eq 0 ]\n")
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,167 @@
+package pazuzu
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zalando-incubator/pazuzu/mock"
+	"github.com/zalando-incubator/pazuzu/shared"
+)
+
+// runScript runs a generated test script with /bin/sh and returns its TAP output.
+func runScript(t *testing.T, script []byte) (string, bool) {
+	directory, err := ioutil.TempDir("", "pazuzu-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "test.sh")
+	if err := ioutil.WriteFile(path, script, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command("/bin/sh", path).Output()
+	return string(output), err == nil
+}
+
+func TestShellRunner(t *testing.T) {
+	features := []shared.Feature{
+		{Meta: shared.FeatureMeta{Name: "passing"}, TestSnippet: "true\necho done"},
+		{Meta: shared.FeatureMeta{Name: "failing"}, TestSnippet: "false\necho unreachable"},
+	}
+
+	var buffer bytes.Buffer
+	if err := (shellRunner{}).WriteTestSpec(&buffer, features); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	output, ok := runScript(t, buffer.Bytes())
+	want := "1..2\nok 1 passing\nnot ok 2 failing\n"
+	if ok || output != want {
+		t.Errorf("test output = %q, %v, want %q, false", output, ok, want)
+	}
+}
+
+func TestChecksRunner(t *testing.T) {
+	features := []shared.Feature{{
+		Meta: shared.FeatureMeta{Name: "shell", TestRunner: TestRunnerChecks},
+		TestSnippet: `
+commands:
+  - run: echo 'hello world'
+    stdout: ^hello
+  - run: exit 3
+    exit_code: 3
+  - run: echo bye
+    stdout: hello
+files:
+  - /bin/sh
+  - /does/not/exist
+`,
+	}}
+
+	var buffer bytes.Buffer
+	if err := (checksRunner{}).WriteTestSpec(&buffer, features); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	output, ok := runScript(t, buffer.Bytes())
+	want := "1..5\n" +
+		"ok 1 shell: echo 'hello world'\n" +
+		"ok 2 shell: exit 3\n" +
+		"not ok 3 shell: echo bye\n" +
+		"# output does not match hello\n" +
+		"ok 4 shell: /bin/sh exists\n" +
+		"not ok 5 shell: /does/not/exist exists\n"
+	if ok || output != want {
+		t.Errorf("test output = %q, %v, want %q, false", output, ok, want)
+	}
+}
+
+func TestChecksRunnerInvalid(t *testing.T) {
+	features := []shared.Feature{{Meta: shared.FeatureMeta{Name: "shell"}, TestSnippet: "commands: ["}}
+	if err := (checksRunner{}).WriteTestSpec(ioutil.Discard, features); err == nil {
+		t.Error("invalid checks should fail")
+	}
+}
+
+func TestGenerateTestFiles(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "python"}, TestSnippet: "@test \"python\" {\n}"},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node", TestRunner: TestRunnerShell}, TestSnippet: "node -v"},
+	)
+
+	pazuzu := Pazuzu{StorageReader: storage}
+	if err := pazuzu.Generate("ubuntu", []string{"python", "node"}); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	var names []string
+	for _, file := range pazuzu.TestFiles {
+		names = append(names, file.Filename)
+		if generated, modified := CheckGenerated(file.Contents); !generated || modified {
+			t.Errorf("%s should be marked as generated:\n%s", file.Filename, file.Contents)
+		}
+	}
+	if want := []string{"test.bats", "test.sh"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("test files = %v, want %v", names, want)
+	}
+	if strings.Contains(string(pazuzu.TestSpec), "node -v") {
+		t.Errorf("shell tests should not be in test.bats:\n%s", pazuzu.TestSpec)
+	}
+	if !strings.Contains(string(pazuzu.TestFiles[1].Contents), "node -v") {
+		t.Errorf("shell tests should be in test.sh:\n%s", pazuzu.TestFiles[1].Contents)
+	}
+}
+
+func TestGenerateUnknownTestRunner(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "python", TestRunner: "pytest"}},
+	)
+
+	pazuzu := Pazuzu{StorageReader: storage}
+	if err := pazuzu.Generate("ubuntu", []string{"python"}); err == nil {
+		t.Error("unknown test runner should fail")
+	}
+}
+
+func TestFeatureTestFiles(t *testing.T) {
+	storage := mock.NewFeatureStorage(
+		shared.Feature{Meta: shared.FeatureMeta{Name: "python"}, TestSnippet: "@test \"python\" {\n}"},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "curl"}},
+		shared.Feature{Meta: shared.FeatureMeta{Name: "node", TestRunner: TestRunnerShell}, TestSnippet: "node -v"},
+	)
+
+	pazuzu := Pazuzu{StorageReader: storage}
+	if err := pazuzu.Generate("ubuntu", []string{"python", "curl", "node"}); err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	files, err := pazuzu.testFiles()
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Feature+":"+file.Filename)
+	}
+	if want := []string{"python:tests/python/test.bats", "node:tests/node/test.sh"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("test files = %v, want %v", names, want)
+	}
+
+	archive, err := testArchive(files, "")
+	if err != nil {
+		t.Fatalf("should not fail: %s", err)
+	}
+	entries := archiveNames(t, archive)
+	for _, name := range []string{"pazuzu/tests/", "pazuzu/tests/python/", "pazuzu/tests/node/test.sh"} {
+		if _, ok := entries[name]; !ok {
+			t.Errorf("archive should contain %s: %v", name, entries)
+		}
+	}
+}